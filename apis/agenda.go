@@ -0,0 +1,133 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/pyrin"
+)
+
+type GetProjectAgenda struct {
+	Overdue  []Task `json:"overdue"`
+	Today    []Task `json:"today"`
+	ThisWeek []Task `json:"thisWeek"`
+	Later    []Task `json:"later"`
+}
+
+type agendaBucket int
+
+const (
+	agendaOverdue agendaBucket = iota
+	agendaToday
+	agendaThisWeek
+	agendaLater
+)
+
+// getAgendaBucket sorts the due date into a bucket relative to now, the
+// weeks starts on monday
+func getAgendaBucket(due int64, hasTime bool, now time.Time) agendaBucket {
+	loc := now.Location()
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	daysLeft := (8 - int(today.Weekday())) % 7
+	if daysLeft == 0 {
+		daysLeft = 7
+	}
+	nextWeek := today.AddDate(0, 0, daysLeft)
+
+	var date time.Time
+	if hasTime {
+		t := time.UnixMilli(due).In(loc)
+		if t.Before(now) {
+			return agendaOverdue
+		}
+
+		date = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	} else {
+		// NOTE(patrik): Dates without time is stored as midnight UTC
+		t := time.UnixMilli(due).UTC()
+		date = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+
+	switch {
+	case date.Before(today):
+		return agendaOverdue
+	case date.Equal(today):
+		return agendaToday
+	case date.Before(nextWeek):
+		return agendaThisWeek
+	default:
+		return agendaLater
+	}
+}
+
+func InstallAgendaHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetProjectAgenda",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/agenda",
+			ResponseType: GetProjectAgenda{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				tasks, err := app.DB().GetTasksByProject(ctx, project.Id, database.TaskFilter{
+					Archived: database.TaskArchivedHide,
+				}, database.TaskSortDue)
+				if err != nil {
+					return nil, err
+				}
+
+				now := time.Now().In(UserLocation(user))
+
+				res := GetProjectAgenda{
+					Overdue:  []Task{},
+					Today:    []Task{},
+					ThisWeek: []Task{},
+					Later:    []Task{},
+				}
+
+				for _, task := range tasks {
+					if !task.Due.Valid || task.Done {
+						continue
+					}
+
+					t, err := ConvertDBTask(task)
+					if err != nil {
+						return nil, err
+					}
+
+					switch getAgendaBucket(task.Due.Int64, task.DueHasTime, now) {
+					case agendaOverdue:
+						res.Overdue = append(res.Overdue, t)
+					case agendaToday:
+						res.Today = append(res.Today, t)
+					case agendaThisWeek:
+						res.ThisWeek = append(res.ThisWeek, t)
+					case agendaLater:
+						res.Later = append(res.Later, t)
+					}
+				}
+
+				return res, nil
+			},
+		},
+	)
+}
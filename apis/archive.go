@@ -0,0 +1,139 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+)
+
+// AutoArchiveTasks archives the tasks that has been on the done board for
+// longer than the auto archive days of the project, returns the number of
+// archived tasks. The project owner is recorded as the one archiving the
+// task inside the history.
+func AutoArchiveTasks(ctx context.Context, app core.App) (int, error) {
+	now := time.Now().UnixMilli()
+
+	tasks, err := app.DB().GetAutoArchiveTasks(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(tasks) == 0 {
+		return 0, nil
+	}
+
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	owners := make(map[string]string)
+
+	for _, task := range tasks {
+		ownerId, exists := owners[task.ProjectId]
+		if !exists {
+			project, err := db.GetProjectById(ctx, task.ProjectId)
+			if err != nil {
+				return 0, err
+			}
+
+			ownerId = project.OwnerId
+			owners[task.ProjectId] = ownerId
+		}
+
+		changes := database.TaskChanges{
+			Archived: types.Change[sql.NullInt64]{
+				Value: sql.NullInt64{
+					Int64: now,
+					Valid: true,
+				},
+				Changed: true,
+			},
+		}
+
+		err = recordTaskHistory(ctx, db, ownerId, task, changes, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		err = db.UpdateTask(ctx, task.Id, changes)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(tasks), nil
+}
+
+func InstallArchiveHandlers(app core.App, group pyrin.Group) {
+	setArchived := func(c pyrin.Context, archived bool) error {
+		taskId := c.Param("taskId")
+
+		ctx := context.TODO()
+
+		user, err := User(app, c)
+		if err != nil {
+			return err
+		}
+
+		task, _, err := UserTask(ctx, app, user, taskId)
+		if err != nil {
+			return err
+		}
+
+		if task.Archived.Valid == archived {
+			return nil
+		}
+
+		value := sql.NullInt64{}
+		if archived {
+			value = sql.NullInt64{
+				Int64: time.Now().UnixMilli(),
+				Valid: true,
+			}
+		}
+
+		changes := database.TaskChanges{
+			Archived: types.Change[sql.NullInt64]{
+				Value:   value,
+				Changed: true,
+			},
+		}
+
+		return updateTaskWithHistory(ctx, app, user, task, changes, nil)
+	}
+
+	group.Register(
+		pyrin.ApiHandler{
+			Name:   "ArchiveTask",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/archive",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setArchived(c, true)
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "UnarchiveTask",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/unarchive",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setArchived(c, false)
+			},
+		},
+	)
+}
@@ -0,0 +1,614 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/core/log"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+)
+
+const attachmentOriginal = "original"
+
+var attachmentThumbnails = []struct {
+	Name string
+	Size int
+}{
+	{Name: "small", Size: 128},
+	{Name: "medium", Size: 512},
+	{Name: "large", Size: 1024},
+}
+
+type Attachment struct {
+	Id          string        `json:"id"`
+	TaskId      string        `json:"taskId"`
+	Filename    string        `json:"filename"`
+	ContentType string        `json:"contentType"`
+	Size        int64         `json:"size"`
+	Url         string        `json:"url"`
+	Images      *types.Images `json:"images"`
+	Created     int64         `json:"created"`
+}
+
+type GetTaskAttachments struct {
+	Attachments []Attachment `json:"attachments"`
+}
+
+type UploadTaskAttachments struct {
+	Ids []string `json:"ids"`
+}
+
+func attachmentUrl(c pyrin.Context, id, file string) string {
+	return ConvertURL(c, fmt.Sprintf("/api/v1/files/attachments/%s/%s", id, file))
+}
+
+func ConvertDBAttachment(c pyrin.Context, attachment database.Attachment) Attachment {
+	original := attachmentUrl(c, attachment.Id, attachmentOriginal)
+
+	var images *types.Images
+	if isThumbnailType(attachment.ContentType) {
+		// NOTE(patrik): Fallback to the original when the thumbnails
+		// couldn't be generated
+		images = &types.Images{
+			Original: original,
+			Small:    original,
+			Medium:   original,
+			Large:    original,
+		}
+
+		if attachment.HasThumbnails {
+			images.Small = attachmentUrl(c, attachment.Id, "small")
+			images.Medium = attachmentUrl(c, attachment.Id, "medium")
+			images.Large = attachmentUrl(c, attachment.Id, "large")
+		}
+	}
+
+	return Attachment{
+		Id:          attachment.Id,
+		TaskId:      attachment.TaskId,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Url:         original,
+		Images:      images,
+		Created:     attachment.Created,
+	}
+}
+
+func isThumbnailType(contentType string) bool {
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+
+	return false
+}
+
+func isUploadTypeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for _, t := range allowed {
+		if t == contentType {
+			return true
+		}
+
+		prefix, found := strings.CutSuffix(t, "/*")
+		if found && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectContentType sniffs the content type from the start of the file and
+// falls back to the extension when the content is unknown
+func detectContentType(f multipart.File, filename string) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(buf[:n])
+	if contentType == "application/octet-stream" {
+		if t := mime.TypeByExtension(filepath.Ext(filename)); t != "" {
+			contentType = t
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "application/octet-stream", nil
+	}
+
+	return mediaType, nil
+}
+
+// attachmentFile finds the stored file inside the attachment directory, the
+// files are stored with the extension of the uploaded file
+func attachmentFile(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entry := range entries {
+		n := entry.Name()
+		if strings.TrimSuffix(n, filepath.Ext(n)) == name {
+			return path.Join(dir, n), nil
+		}
+	}
+
+	return "", os.ErrNotExist
+}
+
+func createThumbnails(dir, original string) bool {
+	for _, thumbnail := range attachmentThumbnails {
+		out := path.Join(dir, thumbnail.Name+".png")
+
+		err := utils.CreateResizedImage(original, out, thumbnail.Size)
+		if err != nil {
+			log.Warn("Failed to create thumbnail", "file", original, "err", err)
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkAttachment makes sure the uploaded file can be saved, returns the
+// detected content type
+func checkAttachment(app core.App, header *multipart.FileHeader) (string, error) {
+	config := app.Config()
+
+	if header.Size > config.MaxUploadSize {
+		return "", FileTooLarge(config.MaxUploadSize)
+	}
+
+	f, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	contentType, err := detectContentType(f, header.Filename)
+	if err != nil {
+		return "", err
+	}
+
+	if !isUploadTypeAllowed(config.AllowedUploadTypes, contentType) {
+		return "", FileTypeNotAllowed(contentType)
+	}
+
+	return contentType, nil
+}
+
+type storedAttachment struct {
+	Id            string
+	Dir           string
+	HasThumbnails bool
+}
+
+// storeAttachment writes the file checked by checkAttachment and the
+// thumbnails to the attachment directory, the caller creates the
+// attachment and removes the directory if that fails. The files is written
+// outside of any transaction so the database isn't locked while copying.
+func storeAttachment(app core.App, header *multipart.FileHeader, contentType string) (storedAttachment, error) {
+	f, err := header.Open()
+	if err != nil {
+		return storedAttachment{}, err
+	}
+	defer f.Close()
+
+	id := utils.CreateAttachmentId()
+	dir := path.Join(app.WorkDir().Attachments(), id)
+
+	err = os.Mkdir(dir, 0755)
+	if err != nil {
+		return storedAttachment{}, err
+	}
+
+	success := false
+	defer func() {
+		if !success {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	original := path.Join(dir, attachmentOriginal+ext)
+
+	out, err := os.Create(original)
+	if err != nil {
+		return storedAttachment{}, err
+	}
+
+	_, err = io.Copy(out, f)
+	out.Close()
+	if err != nil {
+		return storedAttachment{}, err
+	}
+
+	hasThumbnails := false
+	if isThumbnailType(contentType) {
+		hasThumbnails = createThumbnails(dir, original)
+	}
+
+	success = true
+
+	return storedAttachment{
+		Id:            id,
+		Dir:           dir,
+		HasThumbnails: hasThumbnails,
+	}, nil
+}
+
+// NOTE(patrik): Max number of files in a single upload, together with the
+// max upload size it limits the size of the request
+const maxAttachmentFiles = 10
+
+// NOTE(patrik): Room for the multipart headers and boundaries
+const uploadRequestOverhead = 1024 * 1024
+
+// limitUploadBody caps the size of the request body before the multipart
+// form is parsed, pyrin parses the whole form before the handler runs
+func limitUploadBody(app core.App) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			max := app.Config().MaxUploadSize
+			limit := max*maxAttachmentFiles + uploadRequestOverhead
+
+			req := c.Request()
+			req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
+
+			err := next(c)
+
+			var e *http.MaxBytesError
+			if errors.As(err, &e) {
+				return FileTooLarge(max)
+			}
+
+			return err
+		}
+	}
+}
+
+func UserAttachment(ctx context.Context, app core.App, user *database.User, attachmentId string) (database.Attachment, error) {
+	attachment, err := app.DB().GetAttachmentById(ctx, attachmentId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Attachment{}, AttachmentNotFound()
+		}
+
+		return database.Attachment{}, err
+	}
+
+	project, err := app.DB().GetProjectById(ctx, attachment.ProjectId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Attachment{}, AttachmentNotFound()
+		}
+
+		return database.Attachment{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Attachment{}, AttachmentNotFound()
+	}
+
+	return attachment, nil
+}
+
+func InstallAttachmentHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskAttachments",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/attachments",
+			ResponseType: GetTaskAttachments{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				attachments, err := app.DB().GetTaskAttachments(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskAttachments{
+					Attachments: make([]Attachment, len(attachments)),
+				}
+
+				for i, attachment := range attachments {
+					res.Attachments[i] = ConvertDBAttachment(c, attachment)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.FormApiHandler{
+			Name:         "UploadTaskAttachments",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/attachments",
+			ResponseType: UploadTaskAttachments{},
+			Spec: pyrin.FormSpec{
+				Files: map[string]pyrin.FormFileSpec{
+					"files": {
+						NumExpected: 1,
+					},
+				},
+			},
+			Errors: []pyrin.ErrorType{
+				ErrTypeTaskNotFound,
+				ErrTypeFileTooLarge,
+				ErrTypeFileTypeNotAllowed,
+			},
+			Middlewares: []echo.MiddlewareFunc{
+				limitUploadBody(app),
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				files, err := pyrin.FormFiles(c, "files")
+				if err != nil {
+					return nil, err
+				}
+
+				if len(files) > maxAttachmentFiles {
+					return nil, pyrin.ValidationError(map[string]string{
+						"files": fmt.Sprintf("expected at most %d files, got %d", maxAttachmentFiles, len(files)),
+					})
+				}
+
+				// NOTE(patrik): Every file is checked before anything is
+				// saved so a bad file doesn't leave the others saved
+				contentTypes := make([]string, len(files))
+				for i, file := range files {
+					contentTypes[i], err = checkAttachment(app, file)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// NOTE(patrik): Remove the stored files if the attachments
+				// never gets created
+				var stored []storedAttachment
+				success := false
+				defer func() {
+					if !success {
+						for _, item := range stored {
+							os.RemoveAll(item.Dir)
+						}
+					}
+				}()
+
+				for i, file := range files {
+					item, err := storeAttachment(app, file, contentTypes[i])
+					if err != nil {
+						return nil, err
+					}
+
+					stored = append(stored, item)
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				res := UploadTaskAttachments{
+					Ids: make([]string, 0, len(files)),
+				}
+
+				for i, file := range files {
+					_, err := db.CreateAttachment(ctx, database.CreateAttachmentParams{
+						Id:            stored[i].Id,
+						TaskId:        task.Id,
+						UserId:        user.Id,
+						Filename:      filepath.Base(file.Filename),
+						ContentType:   contentTypes[i],
+						Size:          file.Size,
+						HasThumbnails: stored[i].HasThumbnails,
+					})
+					if err != nil {
+						return nil, err
+					}
+
+					res.Ids = append(res.Ids, stored[i].Id)
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				success = true
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteAttachment",
+			Method: http.MethodDelete,
+			Path:   "/attachments/:attachmentId",
+			Errors: []pyrin.ErrorType{ErrTypeAttachmentNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				attachmentId := c.Param("attachmentId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				attachment, err := UserAttachment(ctx, app, user, attachmentId)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				trashId, err := db.CreateTrashItem(ctx, database.CreateTrashItemParams{
+					OwnerId:   user.Id,
+					ProjectId: attachment.ProjectId,
+					Type:      types.TrashTypeAttachment,
+					ItemId:    attachment.Id,
+					Name:      attachment.Filename,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The files is moved into the trash directory
+				// and moved back when the attachment is restored
+				src := path.Join(app.WorkDir().Attachments(), attachment.Id)
+				dst := path.Join(app.WorkDir().Trash(), trashId)
+
+				err = os.Rename(src, dst)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					os.Rename(dst, src)
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		// NOTE(patrik): The files needs the same auth as the rest of the
+		// attachment handlers, attachments in the trash is not reachable
+		pyrin.NormalHandler{
+			Name:   "GetAttachmentFile",
+			Method: http.MethodGet,
+			Path:   "/files/attachments/:attachmentId/:file",
+			HandlerFunc: func(c pyrin.Context) error {
+				attachmentId := c.Param("attachmentId")
+				file := c.Param("file")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return err
+				}
+
+				attachment, err := UserAttachment(ctx, app, user, attachmentId)
+				if err != nil {
+					return err
+				}
+
+				contentType := attachment.ContentType
+
+				switch file {
+				case attachmentOriginal:
+				case "small", "medium", "large":
+					if !attachment.HasThumbnails {
+						return AttachmentNotFound()
+					}
+
+					contentType = "image/png"
+				default:
+					return AttachmentNotFound()
+				}
+
+				dir := path.Join(app.WorkDir().Attachments(), attachment.Id)
+
+				p, err := attachmentFile(dir, file)
+				if err != nil {
+					if errors.Is(err, os.ErrNotExist) {
+						return AttachmentNotFound()
+					}
+
+					return err
+				}
+
+				f, err := os.Open(p)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+
+				stat, err := f.Stat()
+				if err != nil {
+					return err
+				}
+
+				// NOTE(patrik): Only show files inline that the browser can't
+				// run scripts from
+				disposition := "attachment"
+				if isThumbnailType(contentType) || contentType == "application/pdf" || contentType == "text/plain" {
+					disposition = "inline"
+				}
+
+				h := c.Response().Header()
+				h.Set("Content-Type", contentType)
+				h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{
+					"filename": attachment.Filename,
+				}))
+				h.Set("X-Content-Type-Options", "nosniff")
+
+				http.ServeContent(c.Response(), c.Request(), attachment.Filename, stat.ModTime(), f)
+
+				return nil
+			},
+		},
+	)
+}
@@ -98,6 +98,7 @@ type GetMe struct {
 	Role     string `json:"role"`
 
 	DisplayName string `json:"displayName"`
+	Timezone    string `json:"timezone"`
 }
 
 func InstallAuthHandlers(app core.App, group pyrin.Group) {
@@ -241,6 +242,7 @@ func InstallAuthHandlers(app core.App, group pyrin.Group) {
 					Username:    user.Username,
 					Role:        user.Role,
 					DisplayName: displayName,
+					Timezone:    UserLocation(user).String(),
 				}, nil
 			},
 		},
@@ -0,0 +1,335 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+const (
+	BulkActionMove       = "move"
+	BulkActionAddTags    = "add-tags"
+	BulkActionRemoveTags = "remove-tags"
+	BulkActionArchive    = "archive"
+	BulkActionDelete     = "delete"
+)
+
+var bulkActions = []string{
+	BulkActionMove,
+	BulkActionAddTags,
+	BulkActionRemoveTags,
+	BulkActionArchive,
+	BulkActionDelete,
+}
+
+const maxBulkTasks = 500
+
+const (
+	BulkStatusUpdated   = "updated"
+	BulkStatusUnchanged = "unchanged"
+	BulkStatusFailed    = "failed"
+	// NOTE(patrik): The task was valid but nothing was applied because
+	// another task failed
+	BulkStatusSkipped = "skipped"
+)
+
+type BulkTaskResult struct {
+	TaskId string  `json:"taskId"`
+	Status string  `json:"status"`
+	Error  *string `json:"error"`
+}
+
+type BulkTasks struct {
+	// NOTE(patrik): The tasks is only changed when every task is valid,
+	// otherwise nothing is applied
+	Applied bool             `json:"applied"`
+	Results []BulkTaskResult `json:"results"`
+}
+
+type BulkTasksBody struct {
+	TaskIds []string `json:"taskIds"`
+	Action  string   `json:"action"`
+
+	// NOTE(patrik): Used by move
+	BoardId string `json:"boardId,omitempty"`
+	// NOTE(patrik): Used by add-tags and remove-tags
+	Tags []string `json:"tags,omitempty"`
+}
+
+func (b *BulkTasksBody) Transform() {
+	b.Action = transform.String(b.Action)
+	b.BoardId = transform.String(b.BoardId)
+	b.Tags = TransformTags(b.Tags)
+
+	// NOTE(patrik): Every task is only handled once
+	var ids []string
+	for _, id := range b.TaskIds {
+		id = transform.String(id)
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	b.TaskIds = ids
+}
+
+func (b BulkTasksBody) Validate() error {
+	isMove := b.Action == BulkActionMove
+	isTags := b.Action == BulkActionAddTags || b.Action == BulkActionRemoveTags
+
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.TaskIds,
+			validate.Required,
+			validate.Length(1, maxBulkTasks),
+			validate.Each(validate.Required),
+		),
+		validate.Field(&b.Action, validate.Required, validate.In(toAnySlice(bulkActions)...)),
+		validate.Field(&b.BoardId,
+			validate.Required.When(isMove),
+			validate.Empty.When(!isMove),
+		),
+		validate.Field(&b.Tags,
+			validate.Required.When(isTags),
+			validate.Empty.When(!isTags),
+			validate.Each(validate.Required),
+		),
+	)
+}
+
+// applyBulkAction applies the action to a single task inside the
+// transaction, returns false if the task was left unchanged
+func applyBulkAction(ctx context.Context, db *database.Database, user *database.User, project database.Project, task database.Task, body BulkTasksBody, board database.Board) (bool, error) {
+	current := utils.SplitString(task.Tags.String)
+
+	switch body.Action {
+	case BulkActionMove:
+		if task.BoardId == board.Id {
+			return false, nil
+		}
+
+		taskRank, err := db.LastTaskRank(ctx, board.Id)
+		if err != nil {
+			return false, err
+		}
+
+		changes := database.TaskChanges{
+			BoardId: types.Change[string]{
+				Value:   board.Id,
+				Changed: true,
+			},
+			Rank: types.Change[string]{
+				Value:   taskRank,
+				Changed: true,
+			},
+		}
+
+		err = recordTaskHistory(ctx, db, user.Id, task, changes, nil)
+		if err != nil {
+			return false, err
+		}
+
+		err = db.UpdateTask(ctx, task.Id, changes)
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	case BulkActionAddTags, BulkActionRemoveTags:
+		var tags []string
+		if body.Action == BulkActionAddTags {
+			tags = slices.Clone(current)
+			for _, tag := range body.Tags {
+				if !slices.Contains(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+		} else {
+			tags = slices.DeleteFunc(slices.Clone(current), func(tag string) bool {
+				return slices.Contains(body.Tags, tag)
+			})
+		}
+
+		if len(tags) == len(current) {
+			return false, nil
+		}
+
+		err := recordTaskHistory(ctx, db, user.Id, task, database.TaskChanges{}, &tags)
+		if err != nil {
+			return false, err
+		}
+
+		err = updateTaskTags(ctx, db, project.Id, task.Id, current, tags)
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	case BulkActionArchive:
+		if task.Archived.Valid {
+			return false, nil
+		}
+
+		changes := database.TaskChanges{
+			Archived: types.Change[sql.NullInt64]{
+				Value: sql.NullInt64{
+					Int64: time.Now().UnixMilli(),
+					Valid: true,
+				},
+				Changed: true,
+			},
+		}
+
+		err := recordTaskHistory(ctx, db, user.Id, task, changes, nil)
+		if err != nil {
+			return false, err
+		}
+
+		err = db.UpdateTask(ctx, task.Id, changes)
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	case BulkActionDelete:
+		_, err := db.CreateTrashItem(ctx, database.CreateTrashItemParams{
+			OwnerId:   user.Id,
+			ProjectId: project.Id,
+			Type:      types.TrashTypeTask,
+			ItemId:    task.Id,
+			Name:      task.Title,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func InstallBulkHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "BulkTasks",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/tasks/bulk",
+			ResponseType: BulkTasks{},
+			BodyType:     BulkTasksBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound, ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[BulkTasksBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				board := database.Board{}
+				if body.Action == BulkActionMove {
+					board, err = app.DB().GetBoardById(ctx, body.BoardId)
+					if err != nil {
+						if errors.Is(err, database.ErrItemNotFound) {
+							return nil, BoardNotFound()
+						}
+
+						return nil, err
+					}
+
+					if board.ProjectId != project.Id {
+						return nil, BoardNotFound()
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				res := BulkTasks{
+					Results: make([]BulkTaskResult, len(body.TaskIds)),
+				}
+
+				// NOTE(patrik): Every task is checked before anything is
+				// changed so a bad id doesn't leave the tasks half done
+				tasks := make([]database.Task, len(body.TaskIds))
+				failed := false
+
+				for i, id := range body.TaskIds {
+					res.Results[i].TaskId = id
+
+					task, err := db.GetTaskById(ctx, id)
+					if err != nil && !errors.Is(err, database.ErrItemNotFound) {
+						return nil, err
+					}
+
+					// NOTE(patrik): Tasks in other projects is reported as
+					// not found like everywhere else
+					if err != nil || task.ProjectId != project.Id {
+						msg := "task not found"
+						res.Results[i].Status = BulkStatusFailed
+						res.Results[i].Error = &msg
+						failed = true
+						continue
+					}
+
+					tasks[i] = task
+				}
+
+				if failed {
+					for i := range res.Results {
+						if res.Results[i].Status == "" {
+							res.Results[i].Status = BulkStatusSkipped
+						}
+					}
+
+					return res, nil
+				}
+
+				for i, task := range tasks {
+					changed, err := applyBulkAction(ctx, db, user, project, task, body, board)
+					if err != nil {
+						return nil, err
+					}
+
+					res.Results[i].Status = BulkStatusUnchanged
+					if changed {
+						res.Results[i].Status = BulkStatusUpdated
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				res.Applied = true
+
+				return res, nil
+			},
+		},
+	)
+}
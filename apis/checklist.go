@@ -0,0 +1,586 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type ChecklistItem struct {
+	Id      string `json:"id"`
+	Title   string `json:"title"`
+	Checked bool   `json:"checked"`
+}
+
+type Checklist struct {
+	Id    string          `json:"id"`
+	Name  string          `json:"name"`
+	Items []ChecklistItem `json:"items"`
+}
+
+type GetTaskChecklists struct {
+	Checklists []Checklist `json:"checklists"`
+}
+
+type CreateChecklist struct {
+	Id string `json:"id"`
+}
+
+type CreateChecklistBody struct {
+	Name string `json:"name"`
+}
+
+func (b *CreateChecklistBody) Transform() {
+	b.Name = transform.String(b.Name)
+}
+
+func (b CreateChecklistBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required),
+	)
+}
+
+type EditChecklistBody struct {
+	Name  *string `json:"name,omitempty"`
+	Order *int    `json:"order,omitempty"`
+}
+
+func (b *EditChecklistBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+}
+
+func (b EditChecklistBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.Order, validate.Min(0)),
+	)
+}
+
+type CreateChecklistItem struct {
+	Id string `json:"id"`
+}
+
+type CreateChecklistItemBody struct {
+	Title   string `json:"title"`
+	Checked bool   `json:"checked"`
+}
+
+func (b *CreateChecklistItemBody) Transform() {
+	b.Title = transform.String(b.Title)
+}
+
+func (b CreateChecklistItemBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Title, validate.Required),
+	)
+}
+
+type EditChecklistItemBody struct {
+	Title   *string `json:"title,omitempty"`
+	Checked *bool   `json:"checked,omitempty"`
+	Order   *int    `json:"order,omitempty"`
+}
+
+func (b *EditChecklistItemBody) Transform() {
+	b.Title = transform.StringPtr(b.Title)
+}
+
+func (b EditChecklistItemBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Title, validate.Required.When(b.Title != nil)),
+		validate.Field(&b.Order, validate.Min(0)),
+	)
+}
+
+// reorderIds moves the id to the index and returns the new order, an index
+// past the end moves the id to the end
+func reorderIds(ids []string, id string, index int) []string {
+	ids = slices.DeleteFunc(slices.Clone(ids), func(s string) bool {
+		return s == id
+	})
+
+	if index > len(ids) {
+		index = len(ids)
+	}
+
+	return slices.Insert(ids, index, id)
+}
+
+func InstallChecklistHandlers(app core.App, group pyrin.Group) {
+	getChecklist := func(ctx context.Context, task database.Task, checklistId string) (database.TaskChecklist, error) {
+		checklist, err := app.DB().GetTaskChecklistById(ctx, checklistId)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				return database.TaskChecklist{}, ChecklistNotFound()
+			}
+
+			return database.TaskChecklist{}, err
+		}
+
+		if checklist.TaskId != task.Id {
+			return database.TaskChecklist{}, ChecklistNotFound()
+		}
+
+		return checklist, nil
+	}
+
+	getChecklistItem := func(ctx context.Context, checklist database.TaskChecklist, itemId string) (database.TaskChecklistItem, error) {
+		item, err := app.DB().GetTaskChecklistItemById(ctx, itemId)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				return database.TaskChecklistItem{}, ChecklistItemNotFound()
+			}
+
+			return database.TaskChecklistItem{}, err
+		}
+
+		if item.ChecklistId != checklist.Id {
+			return database.TaskChecklistItem{}, ChecklistItemNotFound()
+		}
+
+		return item, nil
+	}
+
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskChecklists",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/checklists",
+			ResponseType: GetTaskChecklists{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklists, err := app.DB().GetTaskChecklists(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskChecklists{
+					Checklists: make([]Checklist, len(checklists)),
+				}
+
+				for i, checklist := range checklists {
+					dbItems, err := app.DB().GetTaskChecklistItems(ctx, checklist.Id)
+					if err != nil {
+						return nil, err
+					}
+
+					items := make([]ChecklistItem, len(dbItems))
+
+					for i, item := range dbItems {
+						items[i] = ChecklistItem{
+							Id:      item.Id,
+							Title:   item.Title,
+							Checked: item.Checked,
+						}
+					}
+
+					res.Checklists[i] = Checklist{
+						Id:    checklist.Id,
+						Name:  checklist.Name,
+						Items: items,
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateChecklist",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/checklists",
+			ResponseType: CreateChecklist{},
+			BodyType:     CreateChecklistBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateChecklistBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklists, err := app.DB().GetTaskChecklists(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				var orderNumber int64 = 0
+				if len(checklists) > 0 {
+					orderNumber = checklists[len(checklists)-1].OrderNumber + 1
+				}
+
+				id, err := app.DB().CreateTaskChecklist(ctx, database.CreateTaskChecklistParams{
+					TaskId:      task.Id,
+					Name:        body.Name,
+					OrderNumber: orderNumber,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateChecklist{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditChecklist",
+			Method:   http.MethodPatch,
+			Path:     "/tasks/:taskId/checklists/:checklistId",
+			BodyType: EditChecklistBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeChecklistNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				checklistId := c.Param("checklistId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditChecklistBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := getChecklist(ctx, task, checklistId)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				if body.Name != nil {
+					err = db.UpdateTaskChecklist(ctx, checklist.Id, database.TaskChecklistChanges{
+						Name: types.Change[string]{
+							Value:   *body.Name,
+							Changed: *body.Name != checklist.Name,
+						},
+					})
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if body.Order != nil {
+					checklists, err := db.GetTaskChecklists(ctx, task.Id)
+					if err != nil {
+						return nil, err
+					}
+
+					ids := make([]string, len(checklists))
+					for i, other := range checklists {
+						ids[i] = other.Id
+					}
+
+					ids = reorderIds(ids, checklist.Id, *body.Order)
+
+					for _, other := range checklists {
+						orderNumber := int64(slices.Index(ids, other.Id))
+
+						err := db.UpdateTaskChecklist(ctx, other.Id, database.TaskChecklistChanges{
+							OrderNumber: types.Change[int64]{
+								Value:   orderNumber,
+								Changed: orderNumber != other.OrderNumber,
+							},
+						})
+						if err != nil {
+							return nil, err
+						}
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteChecklist",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/checklists/:checklistId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeChecklistNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				checklistId := c.Param("checklistId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := getChecklist(ctx, task, checklistId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteTaskChecklist(ctx, checklist.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateChecklistItem",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/checklists/:checklistId/items",
+			ResponseType: CreateChecklistItem{},
+			BodyType:     CreateChecklistItemBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeChecklistNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				checklistId := c.Param("checklistId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateChecklistItemBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := getChecklist(ctx, task, checklistId)
+				if err != nil {
+					return nil, err
+				}
+
+				items, err := app.DB().GetTaskChecklistItems(ctx, checklist.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				var orderNumber int64 = 0
+				if len(items) > 0 {
+					orderNumber = items[len(items)-1].OrderNumber + 1
+				}
+
+				id, err := app.DB().CreateTaskChecklistItem(ctx, database.CreateTaskChecklistItemParams{
+					ChecklistId: checklist.Id,
+					Title:       body.Title,
+					Checked:     body.Checked,
+					OrderNumber: orderNumber,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateChecklistItem{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditChecklistItem",
+			Method:   http.MethodPatch,
+			Path:     "/tasks/:taskId/checklists/:checklistId/items/:itemId",
+			BodyType: EditChecklistItemBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeChecklistNotFound, ErrTypeChecklistItemNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				checklistId := c.Param("checklistId")
+				itemId := c.Param("itemId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditChecklistItemBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := getChecklist(ctx, task, checklistId)
+				if err != nil {
+					return nil, err
+				}
+
+				item, err := getChecklistItem(ctx, checklist, itemId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.TaskChecklistItemChanges{}
+
+				if body.Title != nil {
+					changes.Title = types.Change[string]{
+						Value:   *body.Title,
+						Changed: *body.Title != item.Title,
+					}
+				}
+
+				if body.Checked != nil {
+					changes.Checked = types.Change[bool]{
+						Value:   *body.Checked,
+						Changed: *body.Checked != item.Checked,
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				err = db.UpdateTaskChecklistItem(ctx, item.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				if body.Order != nil {
+					items, err := db.GetTaskChecklistItems(ctx, checklist.Id)
+					if err != nil {
+						return nil, err
+					}
+
+					ids := make([]string, len(items))
+					for i, other := range items {
+						ids[i] = other.Id
+					}
+
+					ids = reorderIds(ids, item.Id, *body.Order)
+
+					for _, other := range items {
+						orderNumber := int64(slices.Index(ids, other.Id))
+
+						err := db.UpdateTaskChecklistItem(ctx, other.Id, database.TaskChecklistItemChanges{
+							OrderNumber: types.Change[int64]{
+								Value:   orderNumber,
+								Changed: orderNumber != other.OrderNumber,
+							},
+						})
+						if err != nil {
+							return nil, err
+						}
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteChecklistItem",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/checklists/:checklistId/items/:itemId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeChecklistNotFound, ErrTypeChecklistItemNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				checklistId := c.Param("checklistId")
+				itemId := c.Param("itemId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := getChecklist(ctx, task, checklistId)
+				if err != nil {
+					return nil, err
+				}
+
+				item, err := getChecklistItem(ctx, checklist, itemId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteTaskChecklistItem(ctx, item.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
@@ -0,0 +1,370 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type CommentAuthor struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type TaskComment struct {
+	Id      string        `json:"id"`
+	Author  CommentAuthor `json:"author"`
+	Content string        `json:"content"`
+
+	ParentId *string `json:"parentId"`
+	Depth    int     `json:"depth"`
+
+	Edited  *int64 `json:"edited"`
+	Created int64  `json:"created"`
+}
+
+type GetTaskComments struct {
+	Comments []TaskComment `json:"comments"`
+}
+
+type CreateTaskComment struct {
+	Id string `json:"id"`
+}
+
+type CreateTaskCommentBody struct {
+	Content  string  `json:"content"`
+	ParentId *string `json:"parentId,omitempty"`
+}
+
+func (b *CreateTaskCommentBody) Transform() {
+	b.Content = transform.String(b.Content)
+	b.ParentId = transform.StringPtr(b.ParentId)
+}
+
+func (b CreateTaskCommentBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Content, validate.Required),
+		validate.Field(&b.ParentId, validate.Required.When(b.ParentId != nil)),
+	)
+}
+
+type EditTaskCommentBody struct {
+	Content string `json:"content"`
+}
+
+func (b *EditTaskCommentBody) Transform() {
+	b.Content = transform.String(b.Content)
+}
+
+func (b EditTaskCommentBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Content, validate.Required),
+	)
+}
+
+// NOTE(patrik): The spec generator doesn't support recursive types so the
+// threads are flattened, every reply comes directly after its parent (or
+// the parents previous replies) and depth tells how far down the thread
+// the comment is. The comments are expected to be sorted by creation time
+func buildCommentThreads(comments []database.TaskComment) []TaskComment {
+	children := make(map[string][]database.TaskComment)
+	var roots []database.TaskComment
+
+	for _, comment := range comments {
+		if comment.ParentId.Valid {
+			children[comment.ParentId.String] = append(children[comment.ParentId.String], comment)
+		} else {
+			roots = append(roots, comment)
+		}
+	}
+
+	res := make([]TaskComment, 0, len(comments))
+
+	var build func(items []database.TaskComment, depth int)
+	build = func(items []database.TaskComment, depth int) {
+		for _, item := range items {
+			displayName := item.Username
+			if item.UserDisplayName.Valid {
+				displayName = item.UserDisplayName.String
+			}
+
+			res = append(res, TaskComment{
+				Id: item.Id,
+				Author: CommentAuthor{
+					Id:          item.UserId,
+					DisplayName: displayName,
+				},
+				Content:  item.Content,
+				ParentId: ConvertSqlNullString(item.ParentId),
+				Depth:    depth,
+				Edited:   ConvertSqlNullInt64(item.Edited),
+				Created:  item.Created,
+			})
+
+			build(children[item.Id], depth+1)
+		}
+	}
+
+	build(roots, 0)
+
+	return res
+}
+
+func InstallCommentHandlers(app core.App, group pyrin.Group) {
+	getComment := func(ctx context.Context, task database.Task, commentId string) (database.TaskComment, error) {
+		comment, err := app.DB().GetTaskCommentById(ctx, commentId)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				return database.TaskComment{}, CommentNotFound()
+			}
+
+			return database.TaskComment{}, err
+		}
+
+		if comment.TaskId != task.Id {
+			return database.TaskComment{}, CommentNotFound()
+		}
+
+		return comment, nil
+	}
+
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskComments",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/comments",
+			ResponseType: GetTaskComments{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				comments, err := app.DB().GetTaskComments(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return GetTaskComments{
+					Comments: buildCommentThreads(comments),
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateTaskComment",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/comments",
+			ResponseType: CreateTaskComment{},
+			BodyType:     CreateTaskCommentBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeCommentNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateTaskCommentBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				parentId := sql.NullString{}
+				if body.ParentId != nil {
+					parent, err := getComment(ctx, task, *body.ParentId)
+					if err != nil {
+						return nil, err
+					}
+
+					parentId = sql.NullString{
+						String: parent.Id,
+						Valid:  true,
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				id, err := db.CreateTaskComment(ctx, database.CreateTaskCommentParams{
+					TaskId:   task.Id,
+					ParentId: parentId,
+					UserId:   user.Id,
+					Content:  body.Content,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				source := sql.NullString{
+					String: id,
+					Valid:  true,
+				}
+
+				err = updateTaskReferences(ctx, db, project, task.Id, source, body.Content)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): Commenting subscribes the user to the task
+				// unless the user has unwatched it
+				err = db.AddTaskWatcher(ctx, task.Id, user.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateTaskComment{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditTaskComment",
+			Method:   http.MethodPatch,
+			Path:     "/tasks/:taskId/comments/:commentId",
+			BodyType: EditTaskCommentBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeCommentNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				commentId := c.Param("commentId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditTaskCommentBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				comment, err := getComment(ctx, task, commentId)
+				if err != nil {
+					return nil, err
+				}
+
+				if body.Content == comment.Content {
+					return nil, nil
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				err = db.UpdateTaskComment(ctx, comment.Id, database.TaskCommentChanges{
+					Content: types.Change[string]{
+						Value:   body.Content,
+						Changed: true,
+					},
+					Edited: types.Change[sql.NullInt64]{
+						Value: sql.NullInt64{
+							Int64: time.Now().UnixMilli(),
+							Valid: true,
+						},
+						Changed: true,
+					},
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				source := sql.NullString{
+					String: comment.Id,
+					Valid:  true,
+				}
+
+				err = updateTaskReferences(ctx, db, project, task.Id, source, body.Content)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteTaskComment",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/comments/:commentId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeCommentNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				commentId := c.Param("commentId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				comment, err := getComment(ctx, task, commentId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteTaskComment(ctx, comment.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
@@ -1,6 +1,7 @@
 package apis
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/nanoteck137/pyrin"
@@ -10,9 +11,41 @@ const (
 	ErrTypeInvalidAuth      pyrin.ErrorType = "INVALID_AUTH"
 	ErrTypeApiTokenNotFound pyrin.ErrorType = "API_TOKEN_NOT_FOUND"
 
-	ErrTypeProjectNotFound pyrin.ErrorType = "PROJECT_NOT_FOUND"
-	ErrTypeBoardNotFound   pyrin.ErrorType = "BOARD_NOT_FOUND"
-	ErrTypeTaskNotFound    pyrin.ErrorType = "TASK_NOT_FOUND"
+	ErrTypeProjectNotFound         pyrin.ErrorType = "PROJECT_NOT_FOUND"
+	ErrTypeProjectKeyAlreadyExists pyrin.ErrorType = "PROJECT_KEY_ALREADY_EXISTS"
+	ErrTypeBoardNotFound           pyrin.ErrorType = "BOARD_NOT_FOUND"
+	ErrTypeTaskNotFound            pyrin.ErrorType = "TASK_NOT_FOUND"
+	ErrTypeCommentNotFound         pyrin.ErrorType = "COMMENT_NOT_FOUND"
+
+	ErrTypeChecklistNotFound     pyrin.ErrorType = "CHECKLIST_NOT_FOUND"
+	ErrTypeChecklistItemNotFound pyrin.ErrorType = "CHECKLIST_ITEM_NOT_FOUND"
+
+	ErrTypeTaskCycle          pyrin.ErrorType = "TASK_CYCLE"
+	ErrTypeTaskProjectChanged pyrin.ErrorType = "TASK_PROJECT_CHANGED"
+
+	ErrTypeTaskRelationNotFound      pyrin.ErrorType = "TASK_RELATION_NOT_FOUND"
+	ErrTypeTaskRelationAlreadyExists pyrin.ErrorType = "TASK_RELATION_ALREADY_EXISTS"
+
+	ErrTypeFieldNotFound pyrin.ErrorType = "FIELD_NOT_FOUND"
+
+	ErrTypeTrashItemNotFound  pyrin.ErrorType = "TRASH_ITEM_NOT_FOUND"
+	ErrTypeTrashParentTrashed pyrin.ErrorType = "TRASH_PARENT_TRASHED"
+
+	ErrTypeHistoryNotFound pyrin.ErrorType = "HISTORY_NOT_FOUND"
+
+	ErrTypeAttachmentNotFound pyrin.ErrorType = "ATTACHMENT_NOT_FOUND"
+	ErrTypeFileTooLarge       pyrin.ErrorType = "FILE_TOO_LARGE"
+	ErrTypeFileTypeNotAllowed pyrin.ErrorType = "FILE_TYPE_NOT_ALLOWED"
+
+	ErrTypeTemplateNotFound pyrin.ErrorType = "TEMPLATE_NOT_FOUND"
+
+	ErrTypeTimeEntryNotFound pyrin.ErrorType = "TIME_ENTRY_NOT_FOUND"
+	ErrTypeTimerNotRunning   pyrin.ErrorType = "TIMER_NOT_RUNNING"
+
+	ErrTypeSprintNotFound pyrin.ErrorType = "SPRINT_NOT_FOUND"
+	ErrTypeSprintClosed   pyrin.ErrorType = "SPRINT_CLOSED"
+
+	ErrTypeMilestoneNotFound pyrin.ErrorType = "MILESTONE_NOT_FOUND"
 
 	ErrTypeUserAlreadyExists pyrin.ErrorType = "USER_ALREADY_EXISTS"
 )
@@ -33,6 +66,14 @@ func ProjectNotFound() *pyrin.Error {
 	}
 }
 
+func ProjectKeyAlreadyExists() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeProjectKeyAlreadyExists,
+		Message: "Project key already exists",
+	}
+}
+
 func BoardNotFound() *pyrin.Error {
 	return &pyrin.Error{
 		Code:    http.StatusNotFound,
@@ -49,6 +90,166 @@ func TaskNotFound() *pyrin.Error {
 	}
 }
 
+func CommentNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeCommentNotFound,
+		Message: "Comment not found",
+	}
+}
+
+func ChecklistNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeChecklistNotFound,
+		Message: "Checklist not found",
+	}
+}
+
+func ChecklistItemNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeChecklistItemNotFound,
+		Message: "Checklist item not found",
+	}
+}
+
+func TaskCycle() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeTaskCycle,
+		Message: "Task would create a cycle",
+	}
+}
+
+func TaskProjectChanged() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeTaskProjectChanged,
+		Message: "Task has been moved to another project since the revision",
+	}
+}
+
+func TaskRelationNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeTaskRelationNotFound,
+		Message: "Task relation not found",
+	}
+}
+
+func TaskRelationAlreadyExists() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeTaskRelationAlreadyExists,
+		Message: "Task relation already exists",
+	}
+}
+
+func FieldNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeFieldNotFound,
+		Message: "Field not found",
+	}
+}
+
+func TrashItemNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeTrashItemNotFound,
+		Message: "Trash item not found",
+	}
+}
+
+func TrashParentTrashed() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeTrashParentTrashed,
+		Message: "The board or project of the item is in the trash, restore it first",
+	}
+}
+
+func HistoryNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeHistoryNotFound,
+		Message: "History entry not found",
+	}
+}
+
+func AttachmentNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeAttachmentNotFound,
+		Message: "Attachment not found",
+	}
+}
+
+func FileTooLarge(max int64) *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusRequestEntityTooLarge,
+		Type:    ErrTypeFileTooLarge,
+		Message: fmt.Sprintf("File is larger than the max size of %d bytes", max),
+	}
+}
+
+func FileTypeNotAllowed(contentType string) *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusUnsupportedMediaType,
+		Type:    ErrTypeFileTypeNotAllowed,
+		Message: fmt.Sprintf("File type '%s' is not allowed", contentType),
+	}
+}
+
+func TemplateNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeTemplateNotFound,
+		Message: "Template not found",
+	}
+}
+
+func TimeEntryNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeTimeEntryNotFound,
+		Message: "Time entry not found",
+	}
+}
+
+func TimerNotRunning() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeTimerNotRunning,
+		Message: "No timer is running",
+	}
+}
+
+func SprintNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeSprintNotFound,
+		Message: "Sprint not found",
+	}
+}
+
+func SprintClosed() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusBadRequest,
+		Type:    ErrTypeSprintClosed,
+		Message: "Sprint is closed",
+	}
+}
+
+func MilestoneNotFound() *pyrin.Error {
+	return &pyrin.Error{
+		Code:    http.StatusNotFound,
+		Type:    ErrTypeMilestoneNotFound,
+		Message: "Milestone not found",
+	}
+}
+
 func ApiTokenNotFound() *pyrin.Error {
 	return &pyrin.Error{
 		Code:    http.StatusNotFound,
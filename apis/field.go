@@ -0,0 +1,703 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+	"github.com/nanoteck137/validate/is"
+)
+
+const fieldDateLayout = "2006-01-02"
+
+type ProjectField struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options"`
+}
+
+type GetProjectFields struct {
+	Fields []ProjectField `json:"fields"`
+}
+
+type CreateProjectField struct {
+	Id string `json:"id"`
+}
+
+// TaskFieldValue is the value of a custom field on a task, only one of the
+// value members is set depending on the type of the field
+type TaskFieldValue struct {
+	FieldId string `json:"fieldId"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+
+	// NOTE(patrik): Used by text, url, date and single-select
+	Text    *string  `json:"text,omitempty"`
+	Number  *float64 `json:"number,omitempty"`
+	Checked *bool    `json:"checked,omitempty"`
+	// NOTE(patrik): Used by multi-select
+	Options []string `json:"options,omitempty"`
+}
+
+var validateFieldType = validate.By(func(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+
+	if !types.IsValidFieldType(types.FieldType(s)) {
+		return errors.New("invalid field type")
+	}
+
+	return nil
+})
+
+var validateUniqueOptions = validate.By(func(value interface{}) error {
+	var options []string
+	switch v := value.(type) {
+	case []string:
+		options = v
+	case *[]string:
+		if v != nil {
+			options = *v
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, option := range options {
+		if seen[option] {
+			return errors.New("duplicate option")
+		}
+
+		seen[option] = true
+	}
+
+	return nil
+})
+
+func transformOptions(options []string) []string {
+	for i, option := range options {
+		options[i] = transform.String(option)
+	}
+
+	return options
+}
+
+type CreateProjectFieldBody struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Options []string `json:"options,omitempty"`
+}
+
+func (b *CreateProjectFieldBody) Transform() {
+	b.Name = transform.String(b.Name)
+	b.Type = transform.String(b.Type)
+	b.Options = transformOptions(b.Options)
+}
+
+func (b CreateProjectFieldBody) Validate() error {
+	hasOptions := types.FieldType(b.Type).HasOptions()
+
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required),
+		validate.Field(&b.Type, validate.Required, validateFieldType),
+		validate.Field(&b.Options,
+			validate.Required.When(hasOptions),
+			validate.Empty.When(!hasOptions),
+			validate.Each(validate.Required),
+			validateUniqueOptions,
+		),
+	)
+}
+
+type EditProjectFieldBody struct {
+	Name    *string   `json:"name,omitempty"`
+	Options *[]string `json:"options,omitempty"`
+}
+
+func (b *EditProjectFieldBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+
+	if b.Options != nil {
+		*b.Options = transformOptions(*b.Options)
+	}
+}
+
+func (b EditProjectFieldBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.Options,
+			validate.Required.When(b.Options != nil),
+			validate.By(func(value interface{}) error {
+				if b.Options == nil {
+					return nil
+				}
+
+				return validate.Validate(*b.Options, validate.Each(validate.Required))
+			}),
+			validateUniqueOptions,
+		),
+	)
+}
+
+// NOTE(patrik): The body can't be validated on its own because the rules
+// depends on the type of the field, see normalizeFieldValue
+type SetTaskFieldBody struct {
+	Text    *string  `json:"text,omitempty"`
+	Number  *float64 `json:"number,omitempty"`
+	Checked *bool    `json:"checked,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
+
+func (b *SetTaskFieldBody) Transform() {
+	b.Text = transform.StringPtr(b.Text)
+	b.Options = transformOptions(b.Options)
+}
+
+func fieldOptions(field database.ProjectField) []string {
+	if !field.Options.Valid {
+		return []string{}
+	}
+
+	var options []string
+	err := json.Unmarshal([]byte(field.Options.String), &options)
+	if err != nil {
+		return []string{}
+	}
+
+	return options
+}
+
+func encodeFieldOptions(typ types.FieldType, options []string) (sql.NullString, error) {
+	if !typ.HasOptions() {
+		return sql.NullString{}, nil
+	}
+
+	d, err := json.Marshal(options)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{
+		String: string(d),
+		Valid:  true,
+	}, nil
+}
+
+func convertValidateError(err error) error {
+	var e validate.Errors
+	if errors.As(err, &e) {
+		extra := make(map[string]string)
+		for k, v := range e {
+			extra[k] = v.Error()
+		}
+
+		return pyrin.ValidationError(extra)
+	}
+
+	return err
+}
+
+func formatFieldNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// normalizeFieldValue validates the body against the type of the field and
+// returns the value in the form it's stored in the database
+func normalizeFieldValue(field database.ProjectField, body SetTaskFieldBody) (string, error) {
+	options := toAnySlice(fieldOptions(field))
+
+	var err error
+	switch field.Type {
+	case types.FieldTypeText:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Text, validate.NotNil),
+		)
+	case types.FieldTypeURL:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Text, validate.Required, is.URL),
+		)
+	case types.FieldTypeDate:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Text, validate.Required, validate.Date(fieldDateLayout)),
+		)
+	case types.FieldTypeSingleSelect:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Text, validate.Required, validate.In(options...)),
+		)
+	case types.FieldTypeMultiSelect:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Options,
+				validate.NotNil,
+				validate.Each(validate.In(options...)),
+				validateUniqueOptions,
+			),
+		)
+	case types.FieldTypeNumber:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Number, validate.NotNil),
+		)
+	case types.FieldTypeCheckbox:
+		err = validate.ValidateStruct(&body,
+			validate.Field(&body.Checked, validate.NotNil),
+		)
+	}
+
+	if err != nil {
+		return "", convertValidateError(err)
+	}
+
+	switch field.Type {
+	case types.FieldTypeMultiSelect:
+		d, err := json.Marshal(body.Options)
+		if err != nil {
+			return "", err
+		}
+
+		return string(d), nil
+	case types.FieldTypeNumber:
+		return formatFieldNumber(*body.Number), nil
+	case types.FieldTypeCheckbox:
+		return strconv.FormatBool(*body.Checked), nil
+	}
+
+	return *body.Text, nil
+}
+
+func convertTaskFieldValue(typ types.FieldType, value string) TaskFieldValue {
+	res := TaskFieldValue{
+		Type: string(typ),
+	}
+
+	switch typ {
+	case types.FieldTypeMultiSelect:
+		res.Options = []string{}
+		_ = json.Unmarshal([]byte(value), &res.Options)
+	case types.FieldTypeNumber:
+		n, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			res.Number = &n
+		}
+	case types.FieldTypeCheckbox:
+		checked := value == "true"
+		res.Checked = &checked
+	default:
+		res.Text = &value
+	}
+
+	return res
+}
+
+type taskFieldItem struct {
+	Id    string          `json:"id"`
+	Name  string          `json:"name"`
+	Type  types.FieldType `json:"type"`
+	Value string          `json:"value"`
+}
+
+// decodeTaskFields decodes the JSON array created by
+// database.TaskFieldValuesQuery
+func decodeTaskFields(fields sql.NullString) ([]taskFieldItem, error) {
+	if !fields.Valid {
+		return nil, nil
+	}
+
+	var items []taskFieldItem
+	err := json.Unmarshal([]byte(fields.String), &items)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ConvertDBTaskFields converts the JSON array created by
+// database.TaskFieldValuesQuery
+func ConvertDBTaskFields(fields sql.NullString) ([]TaskFieldValue, error) {
+	items, err := decodeTaskFields(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]TaskFieldValue, len(items))
+	for i, item := range items {
+		res[i] = convertTaskFieldValue(item.Type, item.Value)
+		res[i].FieldId = item.Id
+		res[i].Name = item.Name
+	}
+
+	return res, nil
+}
+
+// parseTaskFieldFilter reads the custom field filters from the query
+// parameters, the parameters is in the form of field.<fieldId>=<value>
+func parseTaskFieldFilter(c pyrin.Context, fields []database.ProjectField) ([]database.TaskFieldFilter, error) {
+	var res []database.TaskFieldFilter
+
+	for key, values := range c.Request().URL.Query() {
+		fieldId, ok := strings.CutPrefix(key, "field.")
+		if !ok {
+			continue
+		}
+
+		idx := slices.IndexFunc(fields, func(field database.ProjectField) bool {
+			return field.Id == fieldId
+		})
+		if idx == -1 {
+			return nil, pyrin.ValidationError(map[string]string{
+				key: "unknown field",
+			})
+		}
+
+		field := fields[idx]
+
+		for _, value := range values {
+			filter := database.TaskFieldFilter{
+				FieldId: field.Id,
+				Value:   value,
+				Multi:   field.Type == types.FieldTypeMultiSelect,
+			}
+
+			switch field.Type {
+			case types.FieldTypeNumber:
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, pyrin.ValidationError(map[string]string{
+						key: "invalid number",
+					})
+				}
+
+				filter.Value = formatFieldNumber(n)
+			case types.FieldTypeCheckbox:
+				checked, err := strconv.ParseBool(value)
+				if err != nil {
+					return nil, pyrin.ValidationError(map[string]string{
+						key: "invalid boolean",
+					})
+				}
+
+				// NOTE(patrik): Tasks without a value counts as unchecked
+				filter.Value = "true"
+				filter.Not = !checked
+			}
+
+			res = append(res, filter)
+		}
+	}
+
+	return res, nil
+}
+
+// UserProjectField fetches the field and makes sure it belongs to the
+// project
+func UserProjectField(ctx context.Context, app core.App, project database.Project, fieldId string) (database.ProjectField, error) {
+	field, err := app.DB().GetProjectFieldById(ctx, fieldId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.ProjectField{}, FieldNotFound()
+		}
+
+		return database.ProjectField{}, err
+	}
+
+	if field.ProjectId != project.Id {
+		return database.ProjectField{}, FieldNotFound()
+	}
+
+	return field, nil
+}
+
+func InstallFieldHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetProjectFields",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/fields",
+			ResponseType: GetProjectFields{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				fields, err := app.DB().GetProjectFields(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetProjectFields{
+					Fields: make([]ProjectField, len(fields)),
+				}
+
+				for i, field := range fields {
+					res.Fields[i] = ProjectField{
+						Id:      field.Id,
+						Name:    field.Name,
+						Type:    string(field.Type),
+						Options: fieldOptions(field),
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateProjectField",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/fields",
+			ResponseType: CreateProjectField{},
+			BodyType:     CreateProjectFieldBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateProjectFieldBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				typ := types.FieldType(body.Type)
+
+				options, err := encodeFieldOptions(typ, body.Options)
+				if err != nil {
+					return nil, err
+				}
+
+				id, err := app.DB().CreateProjectField(ctx, database.CreateProjectFieldParams{
+					ProjectId: project.Id,
+					Name:      body.Name,
+					Type:      typ,
+					Options:   options,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateProjectField{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditProjectField",
+			Method:   http.MethodPatch,
+			Path:     "/projects/:projectId/fields/:fieldId",
+			BodyType: EditProjectFieldBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeProjectNotFound, ErrTypeFieldNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+				fieldId := c.Param("fieldId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditProjectFieldBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				field, err := UserProjectField(ctx, app, project, fieldId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.ProjectFieldChanges{}
+
+				if body.Name != nil {
+					changes.Name = types.Change[string]{
+						Value:   *body.Name,
+						Changed: *body.Name != field.Name,
+					}
+				}
+
+				if body.Options != nil {
+					if !field.Type.HasOptions() {
+						return nil, pyrin.ValidationError(map[string]string{
+							"options": "field type has no options",
+						})
+					}
+
+					// NOTE(patrik): Values using a removed option is kept
+					// until the task is edited
+					options, err := encodeFieldOptions(field.Type, *body.Options)
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Options = types.Change[sql.NullString]{
+						Value:   options,
+						Changed: options != field.Options,
+					}
+				}
+
+				err = app.DB().UpdateProjectField(ctx, field.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteProjectField",
+			Method: http.MethodDelete,
+			Path:   "/projects/:projectId/fields/:fieldId",
+			Errors: []pyrin.ErrorType{ErrTypeProjectNotFound, ErrTypeFieldNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+				fieldId := c.Param("fieldId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				field, err := UserProjectField(ctx, app, project, fieldId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteProjectField(ctx, field.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "SetTaskField",
+			Method:   http.MethodPut,
+			Path:     "/tasks/:taskId/fields/:fieldId",
+			BodyType: SetTaskFieldBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeFieldNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				fieldId := c.Param("fieldId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[SetTaskFieldBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				field, err := UserProjectField(ctx, app, project, fieldId)
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := normalizeFieldValue(field, body)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().SetTaskFieldValue(ctx, task.Id, field.Id, value)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "RemoveTaskField",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/fields/:fieldId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeFieldNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				fieldId := c.Param("fieldId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				field, err := UserProjectField(ctx, app, project, fieldId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().RemoveTaskFieldValue(ctx, task.Id, field.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
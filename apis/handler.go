@@ -7,6 +7,25 @@ import (
 
 func InstallHandlers(app core.App, g pyrin.Group) {
 	InstallTaskHandlers(app, g)
+	InstallCommentHandlers(app, g)
+	InstallChecklistHandlers(app, g)
+	InstallSubtaskHandlers(app, g)
+	InstallRelationHandlers(app, g)
+	InstallAgendaHandlers(app, g)
+	InstallFieldHandlers(app, g)
+	InstallArchiveHandlers(app, g)
+	InstallTrashHandlers(app, g)
+	InstallHistoryHandlers(app, g)
+	InstallAttachmentHandlers(app, g)
+	InstallTemplateHandlers(app, g)
+	InstallTimeHandlers(app, g)
+	InstallSprintHandlers(app, g)
+	InstallMilestoneHandlers(app, g)
+	InstallTransferHandlers(app, g)
+	InstallQuickAddHandlers(app, g)
+	InstallReferenceHandlers(app, g)
+	InstallBulkHandlers(app, g)
+	InstallWatcherHandlers(app, g)
 	InstallAuthHandlers(app, g)
 	InstallSystemHandlers(app, g)
 	InstallUserHandlers(app, g)
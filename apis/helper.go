@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/nanoteck137/beldum/core"
 	"github.com/nanoteck137/beldum/database"
 	"github.com/nanoteck137/beldum/tools/utils"
 	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/validate"
 )
 
 func User(app core.App, c pyrin.Context) (*database.User, error) {
@@ -73,6 +75,86 @@ func User(app core.App, c pyrin.Context) (*database.User, error) {
 	return nil, InvalidAuth("invalid authorization token")
 }
 
+// UserLocation returns the timezone the user has set in the settings,
+// defaults to UTC
+func UserLocation(user *database.User) *time.Location {
+	if user.Timezone.Valid {
+		loc, err := time.LoadLocation(user.Timezone.String)
+		if err == nil {
+			return loc
+		}
+	}
+
+	return time.UTC
+}
+
+// UserProject fetches the project and makes sure the user is the owner,
+// projects owned by other users are reported as not found
+func UserProject(ctx context.Context, app core.App, user *database.User, projectId string) (database.Project, error) {
+	project, err := app.DB().GetProjectById(ctx, projectId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Project{}, ProjectNotFound()
+		}
+
+		return database.Project{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Project{}, ProjectNotFound()
+	}
+
+	return project, nil
+}
+
+// UserTask fetches the task and makes sure the user owns the project the
+// task belongs to, tasks in other users projects are reported as not found
+func UserTask(ctx context.Context, app core.App, user *database.User, taskId string) (database.Task, database.Project, error) {
+	task, err := app.DB().GetTaskById(ctx, taskId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Task{}, database.Project{}, TaskNotFound()
+		}
+
+		return database.Task{}, database.Project{}, err
+	}
+
+	project, err := app.DB().GetProjectById(ctx, task.ProjectId)
+	if err != nil {
+		return database.Task{}, database.Project{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Task{}, database.Project{}, TaskNotFound()
+	}
+
+	return task, project, nil
+}
+
+// UserBoard fetches the board and makes sure the user owns the project the
+// board belongs to, boards in other users projects are reported as not found
+func UserBoard(ctx context.Context, app core.App, user *database.User, boardId string) (database.Board, error) {
+	board, err := app.DB().GetBoardById(ctx, boardId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Board{}, BoardNotFound()
+		}
+
+		return database.Board{}, err
+	}
+
+	project, err := app.DB().GetProjectById(ctx, board.ProjectId)
+	if err != nil {
+		return database.Board{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Board{}, BoardNotFound()
+	}
+
+	return board, nil
+}
+
 func ConvertSqlNullString(value sql.NullString) *string {
 	if value.Valid {
 		return &value.String
@@ -101,3 +183,33 @@ func ConvertURL(c pyrin.Context, path string) string {
 
 	return fmt.Sprintf("%s://%s%s", scheme, host, path)
 }
+
+var validateDate = validate.By(func(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case *string:
+		if v != nil {
+			s = *v
+		}
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	_, err := time.Parse(utils.DueDateLayout, s)
+	if err != nil {
+		return errors.New("invalid date")
+	}
+
+	return nil
+})
+
+// parseDate parses a validated date, the date is stored as midnight
+// UTC the same way as due dates without time
+func parseDate(s string) int64 {
+	t, _ := time.Parse(utils.DueDateLayout, s)
+	return t.UnixMilli()
+}
@@ -0,0 +1,701 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+)
+
+const (
+	historyFieldTitle       = "title"
+	historyFieldDescription = "description"
+	historyFieldProject     = "project"
+	historyFieldBoard       = "board"
+	historyFieldParent      = "parent"
+	historyFieldRank        = "rank"
+	historyFieldDue         = "due"
+	historyFieldDueHasTime  = "dueHasTime"
+	historyFieldPriority    = "priority"
+	historyFieldEstimate    = "estimate"
+	historyFieldSprint      = "sprint"
+	historyFieldMilestone   = "milestone"
+	historyFieldArchived    = "archived"
+	historyFieldTags        = "tags"
+)
+
+var historyFields = []string{
+	historyFieldTitle,
+	historyFieldDescription,
+	historyFieldProject,
+	historyFieldBoard,
+	historyFieldParent,
+	historyFieldRank,
+	historyFieldDue,
+	historyFieldDueHasTime,
+	historyFieldPriority,
+	historyFieldEstimate,
+	historyFieldSprint,
+	historyFieldMilestone,
+	historyFieldArchived,
+	historyFieldTags,
+}
+
+type TaskHistoryActor struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type TaskHistoryChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type TaskHistoryEntry struct {
+	Id      string              `json:"id"`
+	Actor   TaskHistoryActor    `json:"actor"`
+	Changes []TaskHistoryChange `json:"changes"`
+	Created int64               `json:"created"`
+}
+
+type GetTaskHistory struct {
+	History []TaskHistoryEntry `json:"history"`
+}
+
+func ConvertDBTaskHistory(item database.TaskHistory) (TaskHistoryEntry, error) {
+	changes, err := item.DecodeChanges()
+	if err != nil {
+		return TaskHistoryEntry{}, err
+	}
+
+	displayName := item.Username
+	if item.UserDisplayName.Valid {
+		displayName = item.UserDisplayName.String
+	}
+
+	res := TaskHistoryEntry{
+		Id: item.Id,
+		Actor: TaskHistoryActor{
+			Id:          item.UserId,
+			DisplayName: displayName,
+		},
+		Changes: make([]TaskHistoryChange, len(changes)),
+		Created: item.Created,
+	}
+
+	for i, change := range changes {
+		res.Changes[i] = TaskHistoryChange{
+			Field:  change.Field,
+			Before: change.Before,
+			After:  change.After,
+		}
+	}
+
+	return res, nil
+}
+
+func historyNullInt64(value sql.NullInt64) *string {
+	if !value.Valid {
+		return nil
+	}
+
+	s := strconv.FormatInt(value.Int64, 10)
+	return &s
+}
+
+func historyTags(tags []string) *string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	tags = slices.Clone(tags)
+	slices.Sort(tags)
+
+	s := strings.Join(tags, ",")
+	return &s
+}
+
+// taskHistoryValues formats the tracked fields of the task the way they are
+// stored inside the history
+func taskHistoryValues(task database.Task, tags []string) map[string]*string {
+	dueHasTime := strconv.FormatBool(task.DueHasTime)
+	priority := task.Priority.String()
+
+	return map[string]*string{
+		historyFieldTitle:       &task.Title,
+		historyFieldDescription: ConvertSqlNullString(task.Description),
+		historyFieldProject:     &task.ProjectId,
+		historyFieldBoard:       &task.BoardId,
+		historyFieldParent:      ConvertSqlNullString(task.ParentId),
+		historyFieldRank:        &task.Rank,
+		historyFieldDue:         historyNullInt64(task.Due),
+		historyFieldDueHasTime:  &dueHasTime,
+		historyFieldPriority:    &priority,
+		historyFieldEstimate:    historyNullInt64(task.Estimate),
+		historyFieldSprint:      ConvertSqlNullString(task.SprintId),
+		historyFieldMilestone:   ConvertSqlNullString(task.MilestoneId),
+		historyFieldArchived:    historyNullInt64(task.Archived),
+		historyFieldTags:        historyTags(tags),
+	}
+}
+
+func applyTaskChanges(task database.Task, changes database.TaskChanges) database.Task {
+	if changes.Title.Changed {
+		task.Title = changes.Title.Value
+	}
+
+	if changes.Description.Changed {
+		task.Description = changes.Description.Value
+	}
+
+	if changes.ProjectId.Changed {
+		task.ProjectId = changes.ProjectId.Value
+	}
+
+	if changes.BoardId.Changed {
+		task.BoardId = changes.BoardId.Value
+	}
+
+	if changes.ParentId.Changed {
+		task.ParentId = changes.ParentId.Value
+	}
+
+	if changes.Rank.Changed {
+		task.Rank = changes.Rank.Value
+	}
+
+	if changes.Due.Changed {
+		task.Due = changes.Due.Value
+	}
+
+	if changes.DueHasTime.Changed {
+		task.DueHasTime = changes.DueHasTime.Value
+	}
+
+	if changes.Priority.Changed {
+		task.Priority = changes.Priority.Value
+	}
+
+	if changes.Estimate.Changed {
+		task.Estimate = changes.Estimate.Value
+	}
+
+	if changes.SprintId.Changed {
+		task.SprintId = changes.SprintId.Value
+	}
+
+	if changes.MilestoneId.Changed {
+		task.MilestoneId = changes.MilestoneId.Value
+	}
+
+	if changes.Archived.Changed {
+		task.Archived = changes.Archived.Value
+	}
+
+	return task
+}
+
+func diffHistoryValues(before, after map[string]*string) []database.TaskHistoryChange {
+	var res []database.TaskHistoryChange
+
+	for _, field := range historyFields {
+		a := before[field]
+		b := after[field]
+
+		if a == nil && b == nil {
+			continue
+		}
+
+		if a != nil && b != nil && *a == *b {
+			continue
+		}
+
+		res = append(res, database.TaskHistoryChange{
+			Field:  field,
+			Before: a,
+			After:  b,
+		})
+	}
+
+	return res
+}
+
+// recordTaskHistory records the changes that is about to be made to the task
+// as one history entry, tags is nil when the tags are left untouched
+func recordTaskHistory(ctx context.Context, db *database.Database, userId string, task database.Task, changes database.TaskChanges, tags *[]string) error {
+	current := utils.SplitString(task.Tags.String)
+
+	newTags := current
+	if tags != nil {
+		newTags = *tags
+	}
+
+	before := taskHistoryValues(task, current)
+	after := taskHistoryValues(applyTaskChanges(task, changes), newTags)
+
+	diff := diffHistoryValues(before, after)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	_, err := db.CreateTaskHistory(ctx, database.CreateTaskHistoryParams{
+		TaskId:  task.Id,
+		UserId:  userId,
+		Changes: diff,
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// taskRankFunc computes the new rank of the task, called inside the
+// transaction so the rank is based on the current ranks of the board
+type taskRankFunc func(db *database.Database) (string, error)
+
+// updateTaskWithHistory updates the task and records the changes inside the
+// same transaction, the rank of the task is set from taskRank when not nil
+func updateTaskWithHistory(ctx context.Context, app core.App, user *database.User, task database.Task, changes database.TaskChanges, taskRank taskRankFunc) error {
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if taskRank != nil {
+		value, err := taskRank(db)
+		if err != nil {
+			return err
+		}
+
+		changes.Rank = types.Change[string]{
+			Value:   value,
+			Changed: value != task.Rank,
+		}
+	}
+
+	err = recordTaskHistory(ctx, db, user.Id, task, changes, nil)
+	if err != nil {
+		return err
+	}
+
+	err = db.UpdateTask(ctx, task.Id, changes)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// recordTaskCreated records the initial values of a newly created task so
+// the task can be reverted back to how it was created
+func recordTaskCreated(ctx context.Context, db *database.Database, userId string, task database.Task, tags []string) error {
+	after := taskHistoryValues(task, tags)
+
+	_, err := db.CreateTaskHistory(ctx, database.CreateTaskHistoryParams{
+		TaskId:  task.Id,
+		UserId:  userId,
+		Changes: diffHistoryValues(map[string]*string{}, after),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// revisionValues returns the value every field had right after the revision,
+// the history is expected to be sorted with the newest entry first. Only the
+// fields that was changed after the revision is included.
+func revisionValues(history []database.TaskHistory, revisionId string) (map[string]*string, error) {
+	index := slices.IndexFunc(history, func(item database.TaskHistory) bool {
+		return item.Id == revisionId
+	})
+
+	if index == -1 {
+		return nil, database.ErrItemNotFound
+	}
+
+	res := make(map[string]*string)
+
+	// NOTE(patrik): Walk from the oldest entry after the revision, the first
+	// change of a field holds the value the field had at the revision
+	for i := index - 1; i >= 0; i-- {
+		changes, err := history[i].DecodeChanges()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range changes {
+			if _, exists := res[change.Field]; !exists {
+				res[change.Field] = change.Before
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func parseHistoryInt64(value *string) (sql.NullInt64, error) {
+	if value == nil {
+		return sql.NullInt64{}, nil
+	}
+
+	i, err := strconv.ParseInt(*value, 10, 64)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	return sql.NullInt64{
+		Int64: i,
+		Valid: true,
+	}, nil
+}
+
+func InstallHistoryHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskHistory",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/history",
+			ResponseType: GetTaskHistory{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				history, err := app.DB().GetTaskHistory(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskHistory{
+					History: make([]TaskHistoryEntry, len(history)),
+				}
+
+				for i, item := range history {
+					res.History[i], err = ConvertDBTaskHistory(item)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "RevertTask",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/history/:historyId/revert",
+			Errors: []pyrin.ErrorType{
+				ErrTypeTaskNotFound,
+				ErrTypeHistoryNotFound,
+				ErrTypeBoardNotFound,
+				ErrTypeTaskCycle,
+				ErrTypeSprintNotFound,
+				ErrTypeMilestoneNotFound,
+				ErrTypeTaskProjectChanged,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				historyId := c.Param("historyId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				history, err := app.DB().GetTaskHistory(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				values, err := revisionValues(history, historyId)
+				if err != nil {
+					if errors.Is(err, database.ErrItemNotFound) {
+						return nil, HistoryNotFound()
+					}
+
+					return nil, err
+				}
+
+				current := utils.SplitString(task.Tags.String)
+				target := taskHistoryValues(task, current)
+				for field, value := range values {
+					target[field] = value
+				}
+
+				var tags *[]string
+				changes := database.TaskChanges{}
+
+				diff := diffHistoryValues(taskHistoryValues(task, current), target)
+				for _, change := range diff {
+					value := change.After
+
+					switch change.Field {
+					case historyFieldTitle:
+						if value == nil {
+							continue
+						}
+
+						changes.Title = types.Change[string]{
+							Value:   *value,
+							Changed: true,
+						}
+					case historyFieldDescription:
+						description := sql.NullString{}
+						if value != nil {
+							description = sql.NullString{
+								String: *value,
+								Valid:  true,
+							}
+						}
+
+						changes.Description = types.Change[sql.NullString]{
+							Value:   description,
+							Changed: true,
+						}
+					case historyFieldProject:
+						// NOTE(patrik): The boards, tags and the rest of the
+						// old values belongs to the other project, the task
+						// needs to be moved back before it can be reverted
+						return nil, TaskProjectChanged()
+					case historyFieldBoard:
+						if value == nil {
+							continue
+						}
+
+						board, err := app.DB().GetBoardById(ctx, *value)
+						if err != nil {
+							if errors.Is(err, database.ErrItemNotFound) {
+								return nil, BoardNotFound()
+							}
+
+							return nil, err
+						}
+
+						if board.ProjectId != task.ProjectId {
+							return nil, BoardNotFound()
+						}
+
+						changes.BoardId = types.Change[string]{
+							Value:   board.Id,
+							Changed: true,
+						}
+					case historyFieldParent:
+						parentId := sql.NullString{}
+
+						if value != nil {
+							parent, err := app.DB().GetTaskById(ctx, *value)
+							if err != nil {
+								if errors.Is(err, database.ErrItemNotFound) {
+									return nil, TaskNotFound()
+								}
+
+								return nil, err
+							}
+
+							if parent.ProjectId != task.ProjectId {
+								return nil, TaskNotFound()
+							}
+
+							cycle, err := isTaskAncestor(ctx, app.DB(), parent, task.Id)
+							if err != nil {
+								return nil, err
+							}
+
+							if cycle {
+								return nil, TaskCycle()
+							}
+
+							parentId = sql.NullString{
+								String: parent.Id,
+								Valid:  true,
+							}
+						}
+
+						changes.ParentId = types.Change[sql.NullString]{
+							Value:   parentId,
+							Changed: true,
+						}
+					case historyFieldRank:
+						// NOTE(patrik): Old ranks could collide with the ranks
+						// of the tasks that has been moved since, the position
+						// is only restored together with the board
+					case historyFieldDue:
+						due, err := parseHistoryInt64(value)
+						if err != nil {
+							return nil, err
+						}
+
+						changes.Due = types.Change[sql.NullInt64]{
+							Value:   due,
+							Changed: true,
+						}
+					case historyFieldDueHasTime:
+						changes.DueHasTime = types.Change[bool]{
+							Value:   value != nil && *value == "true",
+							Changed: true,
+						}
+					case historyFieldPriority:
+						priority := types.TaskPriorityNone
+						if value != nil {
+							priority, _ = types.ParseTaskPriority(*value)
+						}
+
+						changes.Priority = types.Change[types.TaskPriority]{
+							Value:   priority,
+							Changed: true,
+						}
+					case historyFieldEstimate:
+						estimate, err := parseHistoryInt64(value)
+						if err != nil {
+							return nil, err
+						}
+
+						changes.Estimate = types.Change[sql.NullInt64]{
+							Value:   estimate,
+							Changed: true,
+						}
+					case historyFieldSprint:
+						sprintId := sql.NullString{}
+
+						if value != nil {
+							sprint, err := projectSprint(ctx, app.DB(), project, *value)
+							if err != nil {
+								return nil, err
+							}
+
+							sprintId = sql.NullString{
+								String: sprint.Id,
+								Valid:  true,
+							}
+						}
+
+						changes.SprintId = types.Change[sql.NullString]{
+							Value:   sprintId,
+							Changed: true,
+						}
+					case historyFieldMilestone:
+						milestoneId := sql.NullString{}
+
+						if value != nil {
+							milestone, err := projectMilestone(ctx, app.DB(), project, *value)
+							if err != nil {
+								return nil, err
+							}
+
+							milestoneId = sql.NullString{
+								String: milestone.Id,
+								Valid:  true,
+							}
+						}
+
+						changes.MilestoneId = types.Change[sql.NullString]{
+							Value:   milestoneId,
+							Changed: true,
+						}
+					case historyFieldArchived:
+						archived, err := parseHistoryInt64(value)
+						if err != nil {
+							return nil, err
+						}
+
+						changes.Archived = types.Change[sql.NullInt64]{
+							Value:   archived,
+							Changed: true,
+						}
+					case historyFieldTags:
+						t := []string{}
+						if value != nil {
+							t = strings.Split(*value, ",")
+						}
+
+						tags = &t
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				// NOTE(patrik): The rank is computed inside the transaction so
+				// concurrent moves doesn't end up with the same rank
+				if changes.BoardId.Changed {
+					taskRank, err := db.LastTaskRank(ctx, changes.BoardId.Value)
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Rank = types.Change[string]{
+						Value:   taskRank,
+						Changed: true,
+					}
+				}
+
+				err = recordTaskHistory(ctx, db, user.Id, task, changes, tags)
+				if err != nil {
+					return nil, err
+				}
+
+				err = db.UpdateTask(ctx, task.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				if tags != nil {
+					err = updateTaskTags(ctx, db, project.Id, task.Id, current, *tags)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if changes.Title.Changed || changes.Description.Changed {
+					err = updateTaskTextReferences(ctx, db, project, applyTaskChanges(task, changes))
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
@@ -0,0 +1,698 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type Milestone struct {
+	Id          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+
+	TargetDate *string `json:"targetDate"`
+	Status     string  `json:"status"`
+
+	TaskCount  int64 `json:"taskCount"`
+	TaskDone   int64 `json:"taskDone"`
+	Points     int64 `json:"points"`
+	PointsDone int64 `json:"pointsDone"`
+
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
+
+type GetMilestones struct {
+	Milestones []Milestone `json:"milestones"`
+}
+
+type CreateMilestone struct {
+	Id string `json:"id"`
+}
+
+type MilestoneProgressBoard struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type MilestoneProgress struct {
+	TaskCount  int64 `json:"taskCount"`
+	TaskDone   int64 `json:"taskDone"`
+	Points     int64 `json:"points"`
+	PointsDone int64 `json:"pointsDone"`
+
+	// NOTE(patrik): Between 0 and 100, based on the story points when the
+	// tasks has estimates and on the number of tasks otherwise
+	Percent float64 `json:"percent"`
+
+	// NOTE(patrik): Days until the target date, negative when the target
+	// date has passed
+	DaysLeft *int64 `json:"daysLeft"`
+	Overdue  bool   `json:"overdue"`
+
+	Boards []MilestoneProgressBoard `json:"boards"`
+}
+
+type ChangelogTask struct {
+	Id    string `json:"id"`
+	Key   string `json:"key"`
+	Title string `json:"title"`
+}
+
+type ChangelogGroup struct {
+	// NOTE(patrik): Empty for the tasks that didn't match any tag
+	Tag   string          `json:"tag"`
+	Tasks []ChangelogTask `json:"tasks"`
+}
+
+type MilestoneChangelog struct {
+	Name       string           `json:"name"`
+	TargetDate *string          `json:"targetDate"`
+	Groups     []ChangelogGroup `json:"groups"`
+}
+
+func ConvertDBMilestone(milestone database.Milestone) Milestone {
+	var targetDate *string
+	if milestone.TargetDate.Valid {
+		s := utils.FormatDue(milestone.TargetDate.Int64, false)
+		targetDate = &s
+	}
+
+	return Milestone{
+		Id:          milestone.Id,
+		Name:        milestone.Name,
+		Description: ConvertSqlNullString(milestone.Description),
+		TargetDate:  targetDate,
+		Status:      string(milestone.Status),
+		TaskCount:   milestone.TaskCount,
+		TaskDone:    milestone.TaskDone,
+		Points:      milestone.Points,
+		PointsDone:  milestone.PointsDone,
+		Created:     milestone.Created,
+		Updated:     milestone.Updated,
+	}
+}
+
+var validateMilestoneStatus = validate.By(func(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case *string:
+		if v != nil {
+			s = *v
+		}
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	if !types.IsValidMilestoneStatus(types.MilestoneStatus(s)) {
+		return errors.New("invalid status")
+	}
+
+	return nil
+})
+
+// parseMilestoneDate parses a validated date, an empty string means no
+// date
+func parseMilestoneDate(s string) sql.NullInt64 {
+	if s == "" {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{
+		Int64: parseDate(s),
+		Valid: true,
+	}
+}
+
+type CreateMilestoneBody struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TargetDate  string `json:"targetDate,omitempty"`
+	Status      string `json:"status,omitempty"`
+}
+
+func (b *CreateMilestoneBody) Transform() {
+	b.Name = transform.String(b.Name)
+	b.Description = transform.String(b.Description)
+	b.TargetDate = transform.String(b.TargetDate)
+	b.Status = transform.String(b.Status)
+}
+
+func (b CreateMilestoneBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required),
+		validate.Field(&b.TargetDate, validateDate),
+		validate.Field(&b.Status, validateMilestoneStatus),
+	)
+}
+
+type EditMilestoneBody struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	TargetDate  *string `json:"targetDate,omitempty"`
+	Status      *string `json:"status,omitempty"`
+}
+
+func (b *EditMilestoneBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+	b.Description = transform.StringPtr(b.Description)
+	b.TargetDate = transform.StringPtr(b.TargetDate)
+	b.Status = transform.StringPtr(b.Status)
+}
+
+func (b EditMilestoneBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.TargetDate, validateDate),
+		validate.Field(&b.Status, validate.Required.When(b.Status != nil), validateMilestoneStatus),
+	)
+}
+
+// projectMilestone fetches the milestone and makes sure it belongs to the
+// project
+func projectMilestone(ctx context.Context, db *database.Database, project database.Project, milestoneId string) (database.Milestone, error) {
+	milestone, err := db.GetMilestoneById(ctx, milestoneId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Milestone{}, MilestoneNotFound()
+		}
+
+		return database.Milestone{}, err
+	}
+
+	if milestone.ProjectId != project.Id {
+		return database.Milestone{}, MilestoneNotFound()
+	}
+
+	return milestone, nil
+}
+
+func UserMilestone(ctx context.Context, app core.App, user *database.User, milestoneId string) (database.Milestone, database.Project, error) {
+	milestone, err := app.DB().GetMilestoneById(ctx, milestoneId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Milestone{}, database.Project{}, MilestoneNotFound()
+		}
+
+		return database.Milestone{}, database.Project{}, err
+	}
+
+	project, err := app.DB().GetProjectById(ctx, milestone.ProjectId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Milestone{}, database.Project{}, MilestoneNotFound()
+		}
+
+		return database.Milestone{}, database.Project{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Milestone{}, database.Project{}, MilestoneNotFound()
+	}
+
+	return milestone, project, nil
+}
+
+func milestoneTasks(ctx context.Context, app core.App, project database.Project, milestone database.Milestone) ([]database.Task, error) {
+	return app.DB().GetTasksByProject(ctx, project.Id, database.TaskFilter{
+		MilestoneId: sql.NullString{
+			String: milestone.Id,
+			Valid:  true,
+		},
+	}, database.TaskSortRank)
+}
+
+func buildMilestoneProgress(milestone database.Milestone, tasks []database.Task, boards []database.Board, loc *time.Location, now time.Time) MilestoneProgress {
+	res := MilestoneProgress{
+		TaskCount:  milestone.TaskCount,
+		TaskDone:   milestone.TaskDone,
+		Points:     milestone.Points,
+		PointsDone: milestone.PointsDone,
+		Boards:     make([]MilestoneProgressBoard, len(boards)),
+	}
+
+	switch {
+	case res.Points > 0:
+		res.Percent = float64(res.PointsDone) / float64(res.Points) * 100
+	case res.TaskCount > 0:
+		res.Percent = float64(res.TaskDone) / float64(res.TaskCount) * 100
+	}
+
+	if milestone.TargetDate.Valid {
+		// NOTE(patrik): Compare the calendar dates so the time of day
+		// doesn't matter
+		now = now.In(loc)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		target := time.UnixMilli(milestone.TargetDate.Int64).UTC()
+
+		days := int64(target.Sub(today).Hours() / 24)
+		res.DaysLeft = &days
+
+		open := milestone.Status == types.MilestoneStatusPlanned || milestone.Status == types.MilestoneStatusActive
+		res.Overdue = open && days < 0
+	}
+
+	for i, board := range boards {
+		res.Boards[i] = MilestoneProgressBoard{
+			Id:   board.Id,
+			Name: board.Name,
+		}
+	}
+
+	for _, task := range tasks {
+		index := slices.IndexFunc(res.Boards, func(b MilestoneProgressBoard) bool {
+			return b.Id == task.BoardId
+		})
+
+		if index != -1 {
+			res.Boards[index].Count++
+		}
+	}
+
+	return res
+}
+
+// buildMilestoneChangelog groups the completed tasks of the milestone by
+// tag. Every task is only listed once, under the first of the tags that the
+// task has, the order of the tags decides which group wins. Without any
+// tags all the tags of the tasks is used in alphabetical order.
+func buildMilestoneChangelog(milestone database.Milestone, tasks []database.Task, order []string) MilestoneChangelog {
+	var done []database.Task
+	for _, task := range tasks {
+		if task.Done {
+			done = append(done, task)
+		}
+	}
+
+	slices.SortFunc(done, func(a, b database.Task) int {
+		return int(a.Number - b.Number)
+	})
+
+	if len(order) == 0 {
+		for _, task := range done {
+			for _, tag := range utils.SplitString(task.Tags.String) {
+				if !slices.Contains(order, tag) {
+					order = append(order, tag)
+				}
+			}
+		}
+
+		slices.Sort(order)
+	}
+
+	groups := make([]ChangelogGroup, len(order)+1)
+	for i, tag := range order {
+		groups[i] = ChangelogGroup{
+			Tag:   tag,
+			Tasks: []ChangelogTask{},
+		}
+	}
+	groups[len(order)] = ChangelogGroup{
+		Tasks: []ChangelogTask{},
+	}
+
+	for _, task := range done {
+		tags := utils.SplitString(task.Tags.String)
+
+		index := slices.IndexFunc(order, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+
+		if index == -1 {
+			index = len(order)
+		}
+
+		groups[index].Tasks = append(groups[index].Tasks, ChangelogTask{
+			Id:    task.Id,
+			Key:   utils.FormatTaskKey(task.ProjectKey, task.Number),
+			Title: task.Title,
+		})
+	}
+
+	res := MilestoneChangelog{
+		Name:   milestone.Name,
+		Groups: []ChangelogGroup{},
+	}
+
+	if milestone.TargetDate.Valid {
+		s := utils.FormatDue(milestone.TargetDate.Int64, false)
+		res.TargetDate = &s
+	}
+
+	for _, group := range groups {
+		if len(group.Tasks) > 0 {
+			res.Groups = append(res.Groups, group)
+		}
+	}
+
+	return res
+}
+
+func renderChangelogMarkdown(changelog MilestoneChangelog) string {
+	var b strings.Builder
+
+	b.WriteString("# ")
+	b.WriteString(changelog.Name)
+	if changelog.TargetDate != nil {
+		b.WriteString(" (")
+		b.WriteString(*changelog.TargetDate)
+		b.WriteString(")")
+	}
+	b.WriteString("\n")
+
+	for _, group := range changelog.Groups {
+		name := group.Tag
+		if name == "" {
+			name = "Other"
+		}
+
+		b.WriteString("\n## ")
+		b.WriteString(name)
+		b.WriteString("\n\n")
+
+		for _, task := range group.Tasks {
+			b.WriteString("- ")
+			b.WriteString(task.Title)
+			b.WriteString(" (")
+			b.WriteString(task.Key)
+			b.WriteString(")\n")
+		}
+	}
+
+	return b.String()
+}
+
+// getMilestoneChangelog builds the changelog for the milestone, the tags
+// query parameter is a comma separated list of the tags to group by
+func getMilestoneChangelog(ctx context.Context, app core.App, c pyrin.Context) (MilestoneChangelog, error) {
+	milestoneId := c.Param("milestoneId")
+
+	user, err := User(app, c)
+	if err != nil {
+		return MilestoneChangelog{}, err
+	}
+
+	milestone, project, err := UserMilestone(ctx, app, user, milestoneId)
+	if err != nil {
+		return MilestoneChangelog{}, err
+	}
+
+	tasks, err := milestoneTasks(ctx, app, project, milestone)
+	if err != nil {
+		return MilestoneChangelog{}, err
+	}
+
+	order := TransformTags(utils.SplitString(c.Request().URL.Query().Get("tags")))
+
+	return buildMilestoneChangelog(milestone, tasks, order), nil
+}
+
+func InstallMilestoneHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetMilestones",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/milestones",
+			ResponseType: GetMilestones{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				milestones, err := app.DB().GetMilestonesByProject(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetMilestones{
+					Milestones: make([]Milestone, len(milestones)),
+				}
+
+				for i, milestone := range milestones {
+					res.Milestones[i] = ConvertDBMilestone(milestone)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateMilestone",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/milestones",
+			ResponseType: CreateMilestone{},
+			BodyType:     CreateMilestoneBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateMilestoneBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				status := types.MilestoneStatus(body.Status)
+				if status == "" {
+					status = types.MilestoneStatusPlanned
+				}
+
+				id, err := app.DB().CreateMilestone(ctx, database.CreateMilestoneParams{
+					ProjectId: project.Id,
+					Name:      body.Name,
+					Description: sql.NullString{
+						String: body.Description,
+						Valid:  body.Description != "",
+					},
+					TargetDate: parseMilestoneDate(body.TargetDate),
+					Status:     status,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateMilestone{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditMilestone",
+			Method:   http.MethodPatch,
+			Path:     "/milestones/:milestoneId",
+			BodyType: EditMilestoneBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeMilestoneNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				milestoneId := c.Param("milestoneId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditMilestoneBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				milestone, _, err := UserMilestone(ctx, app, user, milestoneId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.MilestoneChanges{}
+
+				if body.Name != nil {
+					changes.Name = types.Change[string]{
+						Value:   *body.Name,
+						Changed: *body.Name != milestone.Name,
+					}
+				}
+
+				if body.Description != nil {
+					changes.Description = types.Change[sql.NullString]{
+						Value: sql.NullString{
+							String: *body.Description,
+							Valid:  *body.Description != "",
+						},
+						Changed: *body.Description != milestone.Description.String,
+					}
+				}
+
+				if body.TargetDate != nil {
+					targetDate := parseMilestoneDate(*body.TargetDate)
+					changes.TargetDate = types.Change[sql.NullInt64]{
+						Value:   targetDate,
+						Changed: targetDate != milestone.TargetDate,
+					}
+				}
+
+				if body.Status != nil {
+					status := types.MilestoneStatus(*body.Status)
+					changes.Status = types.Change[types.MilestoneStatus]{
+						Value:   status,
+						Changed: status != milestone.Status,
+					}
+				}
+
+				err = app.DB().UpdateMilestone(ctx, milestone.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteMilestone",
+			Method: http.MethodDelete,
+			Path:   "/milestones/:milestoneId",
+			Errors: []pyrin.ErrorType{ErrTypeMilestoneNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				milestoneId := c.Param("milestoneId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				milestone, _, err := UserMilestone(ctx, app, user, milestoneId)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The tasks of the milestone is left without a
+				// milestone
+				err = app.DB().DeleteMilestone(ctx, milestone.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetMilestoneProgress",
+			Method:       http.MethodGet,
+			Path:         "/milestones/:milestoneId/progress",
+			ResponseType: MilestoneProgress{},
+			Errors:       []pyrin.ErrorType{ErrTypeMilestoneNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				milestoneId := c.Param("milestoneId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				milestone, project, err := UserMilestone(ctx, app, user, milestoneId)
+				if err != nil {
+					return nil, err
+				}
+
+				tasks, err := milestoneTasks(ctx, app, project, milestone)
+				if err != nil {
+					return nil, err
+				}
+
+				boards, err := app.DB().GetBoardsByProject(ctx, project.Id, false)
+				if err != nil {
+					return nil, err
+				}
+
+				return buildMilestoneProgress(milestone, tasks, boards, UserLocation(user), time.Now()), nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetMilestoneChangelog",
+			Method:       http.MethodGet,
+			Path:         "/milestones/:milestoneId/changelog",
+			ResponseType: MilestoneChangelog{},
+			Errors:       []pyrin.ErrorType{ErrTypeMilestoneNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				changelog, err := getMilestoneChangelog(ctx, app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				return changelog, nil
+			},
+		},
+
+		pyrin.NormalHandler{
+			Name:   "GetMilestoneChangelogMarkdown",
+			Method: http.MethodGet,
+			Path:   "/milestones/:milestoneId/changelog/markdown",
+			HandlerFunc: func(c pyrin.Context) error {
+				ctx := context.TODO()
+
+				changelog, err := getMilestoneChangelog(ctx, app, c)
+				if err != nil {
+					return err
+				}
+
+				h := c.Response().Header()
+				h.Set("Content-Type", "text/markdown; charset=utf-8")
+				h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+					"filename": "CHANGELOG.md",
+				}))
+
+				_, err = c.Response().Write([]byte(renderChangelogMarkdown(changelog)))
+				return err
+			},
+		},
+	)
+}
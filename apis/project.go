@@ -4,10 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/nanoteck137/beldum/core"
 	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/duerange"
+	"github.com/nanoteck137/beldum/tools/markdown"
+	"github.com/nanoteck137/beldum/tools/rank"
 	"github.com/nanoteck137/beldum/tools/utils"
 	"github.com/nanoteck137/beldum/types"
 	"github.com/nanoteck137/pyrin"
@@ -18,17 +25,44 @@ import (
 type Project struct {
 	Id   string `json:"id"`
 	Name string `json:"name"`
+	Key  string `json:"key"`
+
+	AutoArchiveDays *int64 `json:"autoArchiveDays"`
 }
 
 type Task struct {
 	Id    string `json:"id"`
+	Key   string `json:"key"`
 	Title string `json:"name"`
 
+	Description     *string `json:"description"`
+	DescriptionHtml *string `json:"descriptionHtml"`
+
 	BoardId   string `json:"boardId"`
 	BoardName string `json:"boardName"`
 
+	ParentId *string `json:"parentId"`
+
+	Due      *string `json:"due"`
+	Priority string  `json:"priority"`
+
+	Estimate    *int64  `json:"estimate"`
+	SprintId    *string `json:"sprintId"`
+	MilestoneId *string `json:"milestoneId"`
+
 	Tags []string `json:"tags"`
 
+	ChecklistDone  int64 `json:"checklistDone"`
+	ChecklistTotal int64 `json:"checklistTotal"`
+
+	ChildrenDone  int64 `json:"childrenDone"`
+	ChildrenTotal int64 `json:"childrenTotal"`
+
+	Blocked  bool `json:"blocked"`
+	Archived bool `json:"archived"`
+
+	Fields []TaskFieldValue `json:"fields"`
+
 	Created int64 `json:"created"`
 	Updated int64 `json:"updated"`
 }
@@ -54,18 +88,52 @@ type CreateProject struct {
 
 type CreateProjectBody struct {
 	Name string `json:"name"`
+	Key  string `json:"key,omitempty"`
 }
 
 func (b *CreateProjectBody) Transform() {
 	b.Name = transform.String(b.Name)
+	b.Key = strings.ToUpper(transform.String(b.Key))
 }
 
 func (b CreateProjectBody) Validate() error {
 	return validate.ValidateStruct(&b,
 		validate.Field(&b.Name, validate.Required),
+		validate.Field(&b.Key, validate.Match(utils.ProjectKeyRegex).Error("must be 2-10 uppercase letters or digits starting with a letter")),
+	)
+}
+
+type EditProjectBody struct {
+	Name *string `json:"name,omitempty"`
+
+	// NOTE(patrik): 0 disables the auto archive
+	AutoArchiveDays *int64 `json:"autoArchiveDays,omitempty"`
+}
+
+func (b *EditProjectBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+}
+
+func (b EditProjectBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.AutoArchiveDays, validate.Min(0)),
 	)
 }
 
+func ConvertDBProject(project database.Project) Project {
+	return Project{
+		Id:              project.Id,
+		Name:            project.Name,
+		Key:             project.Key,
+		AutoArchiveDays: ConvertSqlNullInt64(project.AutoArchiveDays),
+	}
+}
+
+type GetTaskByKey struct {
+	Task
+}
+
 type GetProjectBoards struct {
 	Boards []Board `json:"boards"`
 }
@@ -90,8 +158,16 @@ type CreateTask struct {
 }
 
 type CreateTaskBody struct {
-	Title string   `json:"title"`
-	Tags  []string `json:"tags"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Due         string   `json:"due"`
+	Priority    string   `json:"priority"`
+
+	// NOTE(patrik): Story points
+	Estimate    int64  `json:"estimate,omitempty"`
+	SprintId    string `json:"sprintId,omitempty"`
+	MilestoneId string `json:"milestoneId,omitempty"`
 
 	BoardId string `json:"boardId"`
 }
@@ -104,14 +180,244 @@ func TransformTags(arr []string) []string {
 	return arr
 }
 
+var validateDue = validate.By(func(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case *string:
+		if v != nil {
+			s = *v
+		}
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	_, _, err := utils.ParseDue(s, time.UTC)
+	if err != nil {
+		return errors.New("invalid due date")
+	}
+
+	return nil
+})
+
+var validatePriority = validate.In(toAnySlice(types.TaskPriorityNames())...)
+
+// updateTaskTags adds and removes tags on the task so the task ends up with
+// the new set of tags, tags missing from the project is created
+func updateTaskTags(ctx context.Context, db *database.Database, projectId, taskId string, current, tags []string) error {
+	for _, tag := range tags {
+		if slices.Contains(current, tag) {
+			continue
+		}
+
+		err := db.CreateTag(ctx, projectId, tag)
+		if err != nil && !errors.Is(err, database.ErrItemAlreadyExists) {
+			return err
+		}
+
+		err = db.AddTaskTag(ctx, taskId, projectId, tag)
+		if err != nil && !errors.Is(err, database.ErrItemAlreadyExists) {
+			return err
+		}
+	}
+
+	for _, tag := range current {
+		if slices.Contains(tags, tag) {
+			continue
+		}
+
+		err := db.RemoveTaskTag(ctx, taskId, projectId, tag)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createTask creates the task with the tags, records the references and the
+// creation inside the history and subscribes the user to the task. Should be
+// called inside a transaction.
+func createTask(ctx context.Context, db *database.Database, user *database.User, project database.Project, params database.CreateTaskParams, tags []string) (database.Task, error) {
+	params.ProjectId = project.Id
+
+	task, err := db.CreateTask(ctx, params)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	err = updateTaskTags(ctx, db, project.Id, task.Id, nil, tags)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	err = updateTaskTextReferences(ctx, db, project, task)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	err = recordTaskCreated(ctx, db, user.Id, task, tags)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	err = db.AddTaskWatcher(ctx, task.Id, user.Id)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	return task, nil
+}
+
+func toAnySlice[T any](arr []T) []any {
+	res := make([]any, len(arr))
+	for i, v := range arr {
+		res[i] = v
+	}
+
+	return res
+}
+
+// ParseTaskDue converts the due from a request body to the database
+// representation, an empty string means no due date
+func ParseTaskDue(s string, loc *time.Location) (sql.NullInt64, bool, error) {
+	if s == "" {
+		return sql.NullInt64{}, false, nil
+	}
+
+	t, hasTime, err := utils.ParseDue(s, loc)
+	if err != nil {
+		return sql.NullInt64{}, false, err
+	}
+
+	return sql.NullInt64{
+		Int64: t.UnixMilli(),
+		Valid: true,
+	}, hasTime, nil
+}
+
+func ConvertDBTask(task database.Task) (Task, error) {
+	var descriptionHtml *string
+	if task.Description.Valid {
+		html, err := markdown.Render(task.Description.String)
+		if err != nil {
+			return Task{}, err
+		}
+
+		descriptionHtml = &html
+	}
+
+	var due *string
+	if task.Due.Valid {
+		s := utils.FormatDue(task.Due.Int64, task.DueHasTime)
+		due = &s
+	}
+
+	fields, err := ConvertDBTaskFields(task.Fields)
+	if err != nil {
+		return Task{}, err
+	}
+
+	return Task{
+		Id:              task.Id,
+		Key:             utils.FormatTaskKey(task.ProjectKey, task.Number),
+		Title:           task.Title,
+		Description:     ConvertSqlNullString(task.Description),
+		DescriptionHtml: descriptionHtml,
+		BoardId:         task.BoardId,
+		BoardName:       task.BoardName,
+		ParentId:        ConvertSqlNullString(task.ParentId),
+		Due:             due,
+		Priority:        task.Priority.String(),
+		Estimate:        ConvertSqlNullInt64(task.Estimate),
+		SprintId:        ConvertSqlNullString(task.SprintId),
+		MilestoneId:     ConvertSqlNullString(task.MilestoneId),
+		Tags:            utils.SplitString(task.Tags.String),
+		ChecklistDone:   task.ChecklistDone,
+		ChecklistTotal:  task.ChecklistTotal,
+		ChildrenDone:    task.ChildrenDone,
+		ChildrenTotal:   task.ChildrenTotal,
+		Blocked:         task.BlockerCount > 0,
+		Archived:        task.Archived.Valid,
+		Fields:          fields,
+		Created:         task.Created,
+		Updated:         task.Updated,
+	}, nil
+}
+
 func (b *CreateTaskBody) Transform() {
 	b.Title = transform.String(b.Title)
+	b.Description = transform.String(b.Description)
+	b.Due = transform.String(b.Due)
+	b.Priority = transform.String(b.Priority)
+	b.SprintId = transform.String(b.SprintId)
+	b.MilestoneId = transform.String(b.MilestoneId)
 	b.Tags = TransformTags(b.Tags)
 }
 
 func (b CreateTaskBody) Validate() error {
 	return validate.ValidateStruct(&b,
 		validate.Field(&b.Title, validate.Required),
+		validate.Field(&b.Due, validateDue),
+		validate.Field(&b.Priority, validatePriority),
+		validate.Field(&b.Estimate, validate.Min(int64(0))),
+	)
+}
+
+type EditTaskBody struct {
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	Due         *string   `json:"due,omitempty"`
+	Priority    *string   `json:"priority,omitempty"`
+
+	// NOTE(patrik): Zero removes the estimate and an empty sprint or
+	// milestone removes the task from it
+	Estimate    *int64  `json:"estimate,omitempty"`
+	SprintId    *string `json:"sprintId,omitempty"`
+	MilestoneId *string `json:"milestoneId,omitempty"`
+
+	BoardId *string `json:"boardId,omitempty"`
+}
+
+func (b *EditTaskBody) Transform() {
+	b.Title = transform.StringPtr(b.Title)
+	b.Description = transform.StringPtr(b.Description)
+	b.Due = transform.StringPtr(b.Due)
+	b.Priority = transform.StringPtr(b.Priority)
+	b.SprintId = transform.StringPtr(b.SprintId)
+	b.MilestoneId = transform.StringPtr(b.MilestoneId)
+
+	if b.Tags != nil {
+		*b.Tags = TransformTags(*b.Tags)
+	}
+}
+
+func (b EditTaskBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Title, validate.Required.When(b.Title != nil)),
+		validate.Field(&b.BoardId, validate.Required.When(b.BoardId != nil)),
+		validate.Field(&b.Due, validateDue),
+		validate.Field(&b.Priority, validatePriority),
+		validate.Field(&b.Estimate, validate.Min(int64(0))),
+	)
+}
+
+type MoveTaskBody struct {
+	Before *string `json:"before,omitempty"`
+	After  *string `json:"after,omitempty"`
+}
+
+func (b MoveTaskBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Before, validate.Required.When(b.Before != nil)),
+		validate.Field(&b.After,
+			validate.Required.When(b.After != nil),
+			validate.Nil.When(b.Before != nil).Error("cannot be used together with before"),
+		),
 	)
 }
 
@@ -151,6 +457,91 @@ func (b EditBoardBody) Validate() error {
 	)
 }
 
+// parseTaskFilter reads the task filter from the query parameters, dates
+// without time is the start of the date in the users timezone for tasks
+// with time and archived tasks are hidden unless asked for
+func parseTaskFilter(c pyrin.Context, loc *time.Location) (database.TaskFilter, error) {
+	query := c.Request().URL.Query()
+
+	parse := func(name string) (*duerange.Bound, error) {
+		s := query.Get(name)
+		if s == "" {
+			return nil, nil
+		}
+
+		bound, err := duerange.Parse(s, loc)
+		if err != nil {
+			return nil, pyrin.ValidationError(map[string]string{
+				name: "invalid date",
+			})
+		}
+
+		return &bound, nil
+	}
+
+	var filter database.TaskFilter
+	var err error
+
+	filter.Search = strings.TrimSpace(query.Get("q"))
+
+	switch query.Get("archived") {
+	case "", "hide":
+		filter.Archived = database.TaskArchivedHide
+	case "include":
+		filter.Archived = database.TaskArchivedInclude
+	case "only":
+		filter.Archived = database.TaskArchivedOnly
+	default:
+		return database.TaskFilter{}, pyrin.ValidationError(map[string]string{
+			"archived": "invalid value",
+		})
+	}
+
+	filter.DueFrom, err = parse("dueFrom")
+	if err != nil {
+		return database.TaskFilter{}, err
+	}
+
+	filter.DueTo, err = parse("dueTo")
+	if err != nil {
+		return database.TaskFilter{}, err
+	}
+
+	if sprintId := query.Get("sprint"); sprintId != "" {
+		filter.SprintId = sql.NullString{
+			String: sprintId,
+			Valid:  true,
+		}
+	}
+
+	if milestoneId := query.Get("milestone"); milestoneId != "" {
+		filter.MilestoneId = sql.NullString{
+			String: milestoneId,
+			Valid:  true,
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTaskSort reads the sort query parameter, defaults to the manual
+// order of the tasks
+func parseTaskSort(c pyrin.Context) (database.TaskSort, error) {
+	s := c.Request().URL.Query().Get("sort")
+	if s == "" {
+		return database.TaskSortRank, nil
+	}
+
+	sort := database.TaskSort(s)
+	if !database.IsValidTaskSort(sort) {
+		return "", pyrin.ValidationError(map[string]string{
+			"sort": "invalid sort",
+		})
+	}
+
+	return sort, nil
+}
+
 func InstallTaskHandlers(app core.App, group pyrin.Group) {
 	group.Register(
 		pyrin.ApiHandler{
@@ -159,6 +550,7 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 			Path:         "/projects",
 			ResponseType: CreateProject{},
 			BodyType:     CreateProjectBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectKeyAlreadyExists},
 			HandlerFunc: func(c pyrin.Context) (any, error) {
 				ctx := context.TODO()
 
@@ -172,12 +564,43 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, err
 				}
 
-				project, err := app.DB().CreateProject(ctx, database.CreateProjectParams{
-					Name:    body.Name,
-					OwnerId: user.Id,
-				})
-				if err != nil {
-					return nil, err
+				var project database.Project
+				if body.Key != "" {
+					project, err = app.DB().CreateProject(ctx, database.CreateProjectParams{
+						Name:    body.Name,
+						Key:     body.Key,
+						OwnerId: user.Id,
+					})
+					if err != nil {
+						if errors.Is(err, database.ErrItemAlreadyExists) {
+							return nil, ProjectKeyAlreadyExists()
+						}
+
+						return nil, err
+					}
+				} else {
+					// NOTE(patrik): The derived key gets a number appended
+					// if the user already has a project using it
+					base := utils.CreateProjectKey(body.Name)
+					for i := 1; ; i++ {
+						key := base
+						if i > 1 {
+							key = fmt.Sprintf("%s%d", base, i)
+						}
+
+						project, err = app.DB().CreateProject(ctx, database.CreateProjectParams{
+							Name:    body.Name,
+							Key:     key,
+							OwnerId: user.Id,
+						})
+						if err == nil {
+							break
+						}
+
+						if !errors.Is(err, database.ErrItemAlreadyExists) {
+							return nil, err
+						}
+					}
 				}
 
 				_, err = app.DB().CreateBoard(ctx, database.CreateBoardParams{
@@ -245,10 +668,7 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 				}
 
 				for i, project := range projects {
-					res.Projects[i] = Project{
-						Id:   project.Id,
-						Name: project.Name,
-					}
+					res.Projects[i] = ConvertDBProject(project)
 				}
 
 				return res, nil
@@ -285,14 +705,67 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 				}
 
 				return GetProjectById{
-					Project: Project{
-						Id:   project.Id,
-						Name: project.Name,
-					},
+					Project: ConvertDBProject(project),
 				}, nil
 			},
 		},
 
+		pyrin.ApiHandler{
+			Name:     "EditProject",
+			Method:   http.MethodPatch,
+			Path:     "/projects/:projectId",
+			BodyType: EditProjectBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditProjectBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.ProjectChanges{}
+
+				if body.Name != nil {
+					changes.Name = types.Change[string]{
+						Value:   *body.Name,
+						Changed: *body.Name != project.Name,
+					}
+				}
+
+				if body.AutoArchiveDays != nil {
+					days := sql.NullInt64{
+						Int64: *body.AutoArchiveDays,
+						Valid: *body.AutoArchiveDays != 0,
+					}
+
+					changes.AutoArchiveDays = types.Change[sql.NullInt64]{
+						Value:   days,
+						Changed: days != project.AutoArchiveDays,
+					}
+				}
+
+				err = app.DB().UpdateProject(ctx, project.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
 		pyrin.ApiHandler{
 			Name:         "CreateBoard",
 			Method:       http.MethodPost,
@@ -456,6 +929,11 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, ProjectNotFound()
 				}
 
+				sort, err := parseTaskSort(c)
+				if err != nil {
+					return nil, err
+				}
+
 				boards, err := app.DB().GetBoardsByProject(ctx, project.Id, false)
 				if err != nil {
 					return nil, err
@@ -466,7 +944,9 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 				}
 
 				for i, board := range boards {
-					dbItems, err := app.DB().GetTasksByBoard(ctx, board.Id)
+					dbItems, err := app.DB().GetTasksByBoard(ctx, board.Id, database.TaskFilter{
+						Archived: database.TaskArchivedHide,
+					}, sort)
 					if err != nil {
 						return nil, err
 					}
@@ -474,14 +954,9 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					items := make([]Task, len(dbItems))
 
 					for i, item := range dbItems {
-						items[i] = Task{
-							Id:        item.Id,
-							Title:     item.Title,
-							BoardId:   item.BoardId,
-							BoardName: item.BoardName,
-							Tags:      utils.SplitString(item.Tags.String),
-							Created:   item.Created,
-							Updated:   item.Updated,
+						items[i], err = ConvertDBTask(item)
+						if err != nil {
+							return nil, err
 						}
 					}
 
@@ -525,7 +1000,27 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, ProjectNotFound()
 				}
 
-				tasks, err := app.DB().GetTasksByProject(ctx, project.Id)
+				filter, err := parseTaskFilter(c, UserLocation(user))
+				if err != nil {
+					return nil, err
+				}
+
+				fields, err := app.DB().GetProjectFields(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				filter.Fields, err = parseTaskFieldFilter(c, fields)
+				if err != nil {
+					return nil, err
+				}
+
+				sort, err := parseTaskSort(c)
+				if err != nil {
+					return nil, err
+				}
+
+				tasks, err := app.DB().GetTasksByProject(ctx, project.Id, filter, sort)
 				if err != nil {
 					return nil, err
 				}
@@ -535,14 +1030,9 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 				}
 
 				for i, task := range tasks {
-					res.Tasks[i] = Task{
-						Id:        task.Id,
-						Title:     task.Title,
-						BoardId:   task.BoardId,
-						BoardName: task.BoardName,
-						Tags:      utils.SplitString(task.Tags.String),
-						Created:   task.Created,
-						Updated:   task.Updated,
+					res.Tasks[i], err = ConvertDBTask(task)
+					if err != nil {
+						return nil, err
 					}
 				}
 
@@ -663,7 +1153,7 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 			Path:         "/tasks",
 			ResponseType: CreateTask{},
 			BodyType:     CreateTaskBody{},
-			Errors:       []pyrin.ErrorType{ErrTypeBoardNotFound},
+			Errors:       []pyrin.ErrorType{ErrTypeBoardNotFound, ErrTypeSprintNotFound, ErrTypeMilestoneNotFound},
 			HandlerFunc: func(c pyrin.Context) (any, error) {
 				ctx := context.TODO()
 
@@ -695,25 +1185,69 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, ProjectNotFound()
 				}
 
-				task, err := app.DB().CreateTask(ctx, database.CreateTaskParams{
-					Title:     body.Title,
-					ProjectId: project.Id,
-					BoardId:   board.Id,
-				})
+				due, dueHasTime, err := ParseTaskDue(body.Due, UserLocation(user))
 				if err != nil {
 					return nil, err
 				}
 
-				for _, tag := range body.Tags {
-					err := app.DB().CreateTag(ctx, project.Id, tag)
-					if err != nil && !errors.Is(err, database.ErrItemAlreadyExists) {
+				priority, _ := types.ParseTaskPriority(body.Priority)
+
+				sprintId := sql.NullString{}
+				if body.SprintId != "" {
+					sprint, err := projectSprint(ctx, app.DB(), project, body.SprintId)
+					if err != nil {
 						return nil, err
 					}
 
-					err = app.DB().AddTaskTag(ctx, task.Id, project.Id, tag)
-					if err != nil && !errors.Is(err, database.ErrItemAlreadyExists) {
+					sprintId = sql.NullString{
+						String: sprint.Id,
+						Valid:  true,
+					}
+				}
+
+				milestoneId := sql.NullString{}
+				if body.MilestoneId != "" {
+					milestone, err := projectMilestone(ctx, app.DB(), project, body.MilestoneId)
+					if err != nil {
 						return nil, err
 					}
+
+					milestoneId = sql.NullString{
+						String: milestone.Id,
+						Valid:  true,
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				task, err := createTask(ctx, db, user, project, database.CreateTaskParams{
+					Title: body.Title,
+					Description: sql.NullString{
+						String: body.Description,
+						Valid:  body.Description != "",
+					},
+					BoardId:    board.Id,
+					Due:        due,
+					DueHasTime: dueHasTime,
+					Priority:   priority,
+					Estimate: sql.NullInt64{
+						Int64: body.Estimate,
+						Valid: body.Estimate != 0,
+					},
+					SprintId:    sprintId,
+					MilestoneId: milestoneId,
+				}, body.Tags)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
 				}
 
 				return CreateTask{
@@ -723,10 +1257,52 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 		},
 
 		pyrin.ApiHandler{
-			Name:   "DeleteTask",
-			Method: http.MethodDelete,
-			Path:   "/tasks/:taskId",
-			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			Name:         "GetTaskByKey",
+			Method:       http.MethodGet,
+			Path:         "/tasks/by-key/:key",
+			ResponseType: GetTaskByKey{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				key := c.Param("key")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				projectKey, number, ok := utils.ParseTaskKey(key)
+				if !ok {
+					return nil, TaskNotFound()
+				}
+
+				task, err := app.DB().GetTaskByKey(ctx, user.Id, projectKey, number)
+				if err != nil {
+					if errors.Is(err, database.ErrItemNotFound) {
+						return nil, TaskNotFound()
+					}
+
+					return nil, err
+				}
+
+				res, err := ConvertDBTask(task)
+				if err != nil {
+					return nil, err
+				}
+
+				return GetTaskByKey{
+					Task: res,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditTask",
+			Method:   http.MethodPatch,
+			Path:     "/tasks/:taskId",
+			BodyType: EditTaskBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeBoardNotFound, ErrTypeSprintNotFound, ErrTypeMilestoneNotFound},
 			HandlerFunc: func(c pyrin.Context) (any, error) {
 				taskId := c.Param("taskId")
 
@@ -737,6 +1313,11 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, err
 				}
 
+				body, err := pyrin.Body[EditTaskBody](c)
+				if err != nil {
+					return nil, err
+				}
+
 				task, err := app.DB().GetTaskById(ctx, taskId)
 				if err != nil {
 					if errors.Is(err, database.ErrItemNotFound) {
@@ -755,7 +1336,204 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, TaskNotFound()
 				}
 
-				err = app.DB().DeleteTask(ctx, task.Id)
+				changes := database.TaskChanges{}
+
+				if body.Title != nil {
+					changes.Title = types.Change[string]{
+						Value:   *body.Title,
+						Changed: *body.Title != task.Title,
+					}
+				}
+
+				if body.Description != nil {
+					changes.Description = types.Change[sql.NullString]{
+						Value: sql.NullString{
+							String: *body.Description,
+							Valid:  *body.Description != "",
+						},
+						Changed: *body.Description != task.Description.String,
+					}
+				}
+
+				if body.Due != nil {
+					due, hasTime, err := ParseTaskDue(*body.Due, UserLocation(user))
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Due = types.Change[sql.NullInt64]{
+						Value:   due,
+						Changed: due != task.Due,
+					}
+
+					changes.DueHasTime = types.Change[bool]{
+						Value:   hasTime,
+						Changed: hasTime != task.DueHasTime,
+					}
+				}
+
+				if body.Priority != nil {
+					priority, _ := types.ParseTaskPriority(*body.Priority)
+					changes.Priority = types.Change[types.TaskPriority]{
+						Value:   priority,
+						Changed: priority != task.Priority,
+					}
+				}
+
+				if body.Estimate != nil {
+					estimate := sql.NullInt64{
+						Int64: *body.Estimate,
+						Valid: *body.Estimate != 0,
+					}
+
+					changes.Estimate = types.Change[sql.NullInt64]{
+						Value:   estimate,
+						Changed: estimate != task.Estimate,
+					}
+				}
+
+				if body.SprintId != nil {
+					sprintId := sql.NullString{}
+					if *body.SprintId != "" {
+						sprint, err := projectSprint(ctx, app.DB(), project, *body.SprintId)
+						if err != nil {
+							return nil, err
+						}
+
+						sprintId = sql.NullString{
+							String: sprint.Id,
+							Valid:  true,
+						}
+					}
+
+					changes.SprintId = types.Change[sql.NullString]{
+						Value:   sprintId,
+						Changed: sprintId != task.SprintId,
+					}
+				}
+
+				if body.MilestoneId != nil {
+					milestoneId := sql.NullString{}
+					if *body.MilestoneId != "" {
+						milestone, err := projectMilestone(ctx, app.DB(), project, *body.MilestoneId)
+						if err != nil {
+							return nil, err
+						}
+
+						milestoneId = sql.NullString{
+							String: milestone.Id,
+							Valid:  true,
+						}
+					}
+
+					changes.MilestoneId = types.Change[sql.NullString]{
+						Value:   milestoneId,
+						Changed: milestoneId != task.MilestoneId,
+					}
+				}
+
+				if body.BoardId != nil {
+					board, err := app.DB().GetBoardById(ctx, *body.BoardId)
+					if err != nil {
+						if errors.Is(err, database.ErrItemNotFound) {
+							return nil, BoardNotFound()
+						}
+
+						return nil, err
+					}
+
+					if board.ProjectId != task.ProjectId {
+						return nil, BoardNotFound()
+					}
+
+					changes.BoardId = types.Change[string]{
+						Value:   board.Id,
+						Changed: board.Id != task.BoardId,
+					}
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				// NOTE(patrik): The rank is computed inside the transaction so
+				// concurrent moves doesn't end up with the same rank
+				if changes.BoardId.Changed {
+					taskRank, err := db.LastTaskRank(ctx, changes.BoardId.Value)
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Rank = types.Change[string]{
+						Value:   taskRank,
+						Changed: true,
+					}
+				}
+
+				err = recordTaskHistory(ctx, db, user.Id, task, changes, body.Tags)
+				if err != nil {
+					return nil, err
+				}
+
+				err = db.UpdateTask(ctx, task.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				if body.Tags != nil {
+					current := utils.SplitString(task.Tags.String)
+
+					err = updateTaskTags(ctx, db, project.Id, task.Id, current, *body.Tags)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				if changes.Title.Changed || changes.Description.Changed {
+					err = updateTaskTextReferences(ctx, db, project, applyTaskChanges(task, changes))
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteTask",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = MoveToTrash(ctx, app, database.CreateTrashItemParams{
+					OwnerId:   user.Id,
+					ProjectId: project.Id,
+					Type:      types.TrashTypeTask,
+					ItemId:    task.Id,
+					Name:      task.Title,
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -767,10 +1545,11 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 		// TODO(patrik): Move
 		// TODO(patrik): Fix errors
 		pyrin.ApiHandler{
-			Name:   "MoveTask",
-			Method: http.MethodPost,
-			Path:   "/tasks/:taskId/move/:boardId",
-			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			Name:     "MoveTask",
+			Method:   http.MethodPost,
+			Path:     "/tasks/:taskId/move/:boardId",
+			BodyType: MoveTaskBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTaskNotFound},
 			HandlerFunc: func(c pyrin.Context) (any, error) {
 				taskId := c.Param("taskId")
 				boardId := c.Param("boardId")
@@ -782,6 +1561,16 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, err
 				}
 
+				// NOTE(patrik): The body is optional, without it the task is
+				// placed last on the board
+				body, err := pyrin.Body[MoveTaskBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
 				task, err := app.DB().GetTaskById(ctx, taskId)
 				if err != nil {
 					if errors.Is(err, database.ErrItemNotFound) {
@@ -832,12 +1621,76 @@ func InstallTaskHandlers(app core.App, group pyrin.Group) {
 					return nil, errors.New("Project not matching")
 				}
 
-				err = app.DB().UpdateTask(ctx, task.Id, database.TaskChanges{
+				changes := database.TaskChanges{
 					BoardId: types.Change[string]{
 						Value:   dstBoard.Id,
 						Changed: dstBoard.Id != srcBoard.Id,
 					},
-				})
+				}
+
+				var taskRank taskRankFunc
+
+				switch {
+				case body.Before != nil || body.After != nil:
+					name := "before"
+					anchorId := body.Before
+					if body.After != nil {
+						name = "after"
+						anchorId = body.After
+					}
+
+					anchor, _, err := UserTask(ctx, app, user, *anchorId)
+					if err != nil {
+						return nil, err
+					}
+
+					if anchor.BoardId != dstBoard.Id {
+						return nil, pyrin.ValidationError(map[string]string{
+							name: "task is not on the board",
+						})
+					}
+
+					if anchor.Id == task.Id {
+						break
+					}
+
+					// NOTE(patrik): Only the moved task gets a new rank, the
+					// rank is placed between the anchor and its neighbour
+					before := body.Before != nil
+					taskRank = func(db *database.Database) (string, error) {
+						current, err := db.GetTaskById(ctx, anchor.Id)
+						if err != nil {
+							if errors.Is(err, database.ErrItemNotFound) {
+								return "", TaskNotFound()
+							}
+
+							return "", err
+						}
+
+						if current.BoardId != dstBoard.Id {
+							return "", pyrin.ValidationError(map[string]string{
+								name: "task is not on the board",
+							})
+						}
+
+						neighbour, err := db.TaskRankNeighbour(ctx, dstBoard.Id, current.Rank, task.Id, before)
+						if err != nil {
+							return "", err
+						}
+
+						if before {
+							return rank.Between(neighbour, current.Rank)
+						}
+
+						return rank.Between(current.Rank, neighbour)
+					}
+				case changes.BoardId.Changed:
+					taskRank = func(db *database.Database) (string, error) {
+						return db.LastTaskRank(ctx, dstBoard.Id)
+					}
+				}
+
+				err = updateTaskWithHistory(ctx, app, user, task, changes, taskRank)
 				if err != nil {
 					return nil, err
 				}
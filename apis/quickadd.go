@@ -0,0 +1,174 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/quickadd"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type QuickAddParsed struct {
+	Title     string   `json:"title"`
+	Tags      []string `json:"tags"`
+	BoardId   string   `json:"boardId"`
+	BoardName string   `json:"boardName"`
+	Due       *string  `json:"due"`
+	Priority  string   `json:"priority"`
+}
+
+type QuickAdd struct {
+	Parsed QuickAddParsed `json:"parsed"`
+
+	// NOTE(patrik): Null when previewing
+	Id  *string `json:"id"`
+	Key *string `json:"key"`
+}
+
+type QuickAddBody struct {
+	Text string `json:"text"`
+
+	// NOTE(patrik): Only parses the text without creating the task
+	Preview bool `json:"preview,omitempty"`
+}
+
+func (b *QuickAddBody) Transform() {
+	b.Text = transform.String(b.Text)
+}
+
+func (b QuickAddBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Text, validate.Required),
+	)
+}
+
+func quickAddError(err error) error {
+	return pyrin.ValidationError(map[string]string{
+		"text": strings.TrimPrefix(err.Error(), "quickadd: "),
+	})
+}
+
+func InstallQuickAddHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "QuickAddTask",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/quick-add",
+			ResponseType: QuickAdd{},
+			BodyType:     QuickAddBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound, ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[QuickAddBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				loc := UserLocation(user)
+
+				parsed, err := quickadd.Parse(body.Text, time.Now().In(loc))
+				if err != nil {
+					return nil, quickAddError(err)
+				}
+
+				boards, err := app.DB().GetBoardsByProject(ctx, project.Id, false)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(boards) == 0 {
+					return nil, BoardNotFound()
+				}
+
+				// NOTE(patrik): Without a board the task is placed on the
+				// first board like the other ways of creating tasks
+				board := boards[0]
+				if parsed.Board != "" {
+					names := make([]string, len(boards))
+					for i, board := range boards {
+						names[i] = board.Name
+					}
+
+					index, err := quickadd.MatchBoard(names, parsed.Board)
+					if err != nil {
+						return nil, quickAddError(err)
+					}
+
+					board = boards[index]
+				}
+
+				due, dueHasTime, err := ParseTaskDue(parsed.Due, loc)
+				if err != nil {
+					return nil, err
+				}
+
+				res := QuickAdd{
+					Parsed: QuickAddParsed{
+						Title:     parsed.Title,
+						Tags:      parsed.Tags,
+						BoardId:   board.Id,
+						BoardName: board.Name,
+						Priority:  parsed.Priority.String(),
+					},
+				}
+
+				if due.Valid {
+					s := utils.FormatDue(due.Int64, dueHasTime)
+					res.Parsed.Due = &s
+				}
+
+				if body.Preview {
+					return res, nil
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				task, err := createTask(ctx, db, user, project, database.CreateTaskParams{
+					Title:      parsed.Title,
+					BoardId:    board.Id,
+					Due:        due,
+					DueHasTime: dueHasTime,
+					Priority:   parsed.Priority,
+				}, parsed.Tags)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				key := utils.FormatTaskKey(project.Key, task.Number)
+				res.Id = &task.Id
+				res.Key = &key
+
+				return res, nil
+			},
+		},
+	)
+}
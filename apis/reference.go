@@ -0,0 +1,225 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/mention"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/pyrin"
+)
+
+type TaskBacklink struct {
+	TaskId    string  `json:"taskId"`
+	TaskKey   string  `json:"taskKey"`
+	TaskTitle string  `json:"taskTitle"`
+	ProjectId string  `json:"projectId"`
+	CommentId *string `json:"commentId"`
+	Created   int64   `json:"created"`
+}
+
+type GetTaskBacklinks struct {
+	Backlinks []TaskBacklink `json:"backlinks"`
+}
+
+func ConvertDBTaskReference(item database.TaskReference) TaskBacklink {
+	return TaskBacklink{
+		TaskId:    item.TaskId,
+		TaskKey:   utils.FormatTaskKey(item.ProjectKey, item.TaskNumber),
+		TaskTitle: item.TaskTitle,
+		ProjectId: item.ProjectId,
+		CommentId: ConvertSqlNullString(item.CommentId),
+		Created:   item.Created,
+	}
+}
+
+// resolveMentions finds the tasks the mentions points to, only the tasks
+// inside the projects of the owner can be referenced and mentions of
+// unknown tasks is ignored
+func resolveMentions(ctx context.Context, db *database.Database, ownerId string, mentions mention.Mentions) ([]string, error) {
+	var res []string
+
+	add := func(task database.Task) {
+		if !slices.Contains(res, task.Id) {
+			res = append(res, task.Id)
+		}
+	}
+
+	for _, key := range mentions.Keys {
+		projectKey, number, ok := utils.ParseTaskKey(key)
+		if !ok {
+			continue
+		}
+
+		task, err := db.GetTaskByKey(ctx, ownerId, projectKey, number)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		add(task)
+	}
+
+	for _, id := range mentions.Ids {
+		task, err := db.GetTaskById(ctx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		project, err := db.GetProjectById(ctx, task.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+
+		if project.OwnerId != ownerId {
+			continue
+		}
+
+		add(task)
+	}
+
+	return res, nil
+}
+
+// updateTaskReferences replaces the references made by the title and the
+// description of the task, or by the comment when the comment id is set,
+// with the mentions found inside the sources
+func updateTaskReferences(ctx context.Context, db *database.Database, project database.Project, taskId string, commentId sql.NullString, sources ...string) error {
+	targets, err := resolveMentions(ctx, db, project.OwnerId, mention.Extract(sources...))
+	if err != nil {
+		return err
+	}
+
+	targets = slices.DeleteFunc(targets, func(id string) bool {
+		return id == taskId
+	})
+
+	return db.SetTaskReferences(ctx, taskId, commentId, targets)
+}
+
+// updateTaskTextReferences updates the references made by the title and the
+// description of the task
+func updateTaskTextReferences(ctx context.Context, db *database.Database, project database.Project, task database.Task) error {
+	return updateTaskReferences(ctx, db, project, task.Id, sql.NullString{}, task.Title, task.Description.String)
+}
+
+// BackfillTaskReferences records the references made by the titles, the
+// descriptions and the comments of all the tasks, used to pick up the
+// mentions written before the references was tracked. Returns the number of
+// tasks processed.
+func BackfillTaskReferences(ctx context.Context, app core.App) (int, error) {
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	tasks, err := db.GetAllTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	projects := make(map[string]database.Project)
+
+	count := 0
+	for _, task := range tasks {
+		project, exists := projects[task.ProjectId]
+		if !exists {
+			project, err = db.GetProjectById(ctx, task.ProjectId)
+			if err != nil {
+				if errors.Is(err, database.ErrItemNotFound) {
+					continue
+				}
+
+				return 0, err
+			}
+
+			projects[task.ProjectId] = project
+		}
+
+		err = updateTaskTextReferences(ctx, db, project, task)
+		if err != nil {
+			return 0, err
+		}
+
+		comments, err := db.GetTaskComments(ctx, task.Id)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, comment := range comments {
+			source := sql.NullString{
+				String: comment.Id,
+				Valid:  true,
+			}
+
+			err = updateTaskReferences(ctx, db, project, task.Id, source, comment.Content)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		count++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func InstallReferenceHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskBacklinks",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/backlinks",
+			ResponseType: GetTaskBacklinks{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				references, err := app.DB().GetTaskBacklinks(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskBacklinks{
+					Backlinks: make([]TaskBacklink, len(references)),
+				}
+
+				for i, reference := range references {
+					res.Backlinks[i] = ConvertDBTaskReference(reference)
+				}
+
+				return res, nil
+			},
+		},
+	)
+}
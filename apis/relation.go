@@ -0,0 +1,411 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+const (
+	relationBlockedBy    = "blocked-by"
+	relationDuplicatedBy = "duplicated-by"
+)
+
+type TaskRelation struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	TaskId    string `json:"taskId"`
+	TaskTitle string `json:"taskTitle"`
+}
+
+type GetTaskRelations struct {
+	Relations []TaskRelation `json:"relations"`
+}
+
+type CreateTaskRelation struct {
+	Id string `json:"id"`
+}
+
+type CreateTaskRelationBody struct {
+	Type   string `json:"type"`
+	TaskId string `json:"taskId"`
+}
+
+func (b *CreateTaskRelationBody) Transform() {
+	b.Type = transform.String(b.Type)
+	b.TaskId = transform.String(b.TaskId)
+}
+
+func (b CreateTaskRelationBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Type, validate.Required, validate.In(
+			string(types.TaskRelationBlocks),
+			relationBlockedBy,
+			string(types.TaskRelationRelatesTo),
+			string(types.TaskRelationDuplicates),
+		)),
+		validate.Field(&b.TaskId, validate.Required),
+	)
+}
+
+type GraphNode struct {
+	Id        string `json:"id"`
+	Title     string `json:"title"`
+	BoardId   string `json:"boardId"`
+	BoardName string `json:"boardName"`
+	Blocked   bool   `json:"blocked"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+type GetProjectGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	Dot   string      `json:"dot"`
+}
+
+// hasBlockingPath checks if the from task can reach the to task by
+// following the blocking relations
+func hasBlockingPath(relations []database.TaskRelation, from, to string) bool {
+	edges := make(map[string][]string)
+	for _, relation := range relations {
+		if relation.Type == types.TaskRelationBlocks {
+			edges[relation.FromTaskId] = append(edges[relation.FromTaskId], relation.ToTaskId)
+		}
+	}
+
+	visited := make(map[string]bool)
+	stack := []string{from}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if current == to {
+			return true
+		}
+
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+
+		stack = append(stack, edges[current]...)
+	}
+
+	return false
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+func generateDot(name string, nodes []GraphNode, edges []GraphEdge) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(name))
+
+	for _, node := range nodes {
+		attrs := fmt.Sprintf("label=%s", dotQuote(node.Title))
+		if node.Blocked {
+			attrs += ", color=red"
+		}
+
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.Id), attrs)
+	}
+
+	for _, edge := range edges {
+		attrs := fmt.Sprintf("label=%s", dotQuote(edge.Type))
+		switch types.TaskRelationType(edge.Type) {
+		case types.TaskRelationRelatesTo:
+			attrs += ", style=dashed, dir=none"
+		case types.TaskRelationDuplicates:
+			attrs += ", style=dotted"
+		}
+
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), attrs)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func InstallRelationHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskRelations",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/relations",
+			ResponseType: GetTaskRelations{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				relations, err := app.DB().GetTaskRelationsByTask(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskRelations{
+					Relations: make([]TaskRelation, len(relations)),
+				}
+
+				for i, relation := range relations {
+					if relation.FromTaskId == task.Id {
+						res.Relations[i] = TaskRelation{
+							Id:        relation.Id,
+							Type:      string(relation.Type),
+							TaskId:    relation.ToTaskId,
+							TaskTitle: relation.ToTaskTitle,
+						}
+
+						continue
+					}
+
+					typ := string(relation.Type)
+					switch relation.Type {
+					case types.TaskRelationBlocks:
+						typ = relationBlockedBy
+					case types.TaskRelationDuplicates:
+						typ = relationDuplicatedBy
+					}
+
+					res.Relations[i] = TaskRelation{
+						Id:        relation.Id,
+						Type:      typ,
+						TaskId:    relation.FromTaskId,
+						TaskTitle: relation.FromTaskTitle,
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateTaskRelation",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/relations",
+			ResponseType: CreateTaskRelation{},
+			BodyType:     CreateTaskRelationBody{},
+			Errors: []pyrin.ErrorType{
+				ErrTypeTaskNotFound,
+				ErrTypeProjectNotFound,
+				ErrTypeTaskCycle,
+				ErrTypeTaskRelationAlreadyExists,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateTaskRelationBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				other, _, err := UserTask(ctx, app, user, body.TaskId)
+				if err != nil {
+					return nil, err
+				}
+
+				if task.ProjectId != other.ProjectId {
+					return nil, ProjectNotFound()
+				}
+
+				if task.Id == other.Id {
+					return nil, TaskCycle()
+				}
+
+				from := task.Id
+				to := other.Id
+				typ := types.TaskRelationType(body.Type)
+
+				if body.Type == relationBlockedBy {
+					from, to = to, from
+					typ = types.TaskRelationBlocks
+				}
+
+				if typ == types.TaskRelationBlocks {
+					relations, err := app.DB().GetTaskRelationsByProject(ctx, task.ProjectId)
+					if err != nil {
+						return nil, err
+					}
+
+					if hasBlockingPath(relations, to, from) {
+						return nil, TaskCycle()
+					}
+				}
+
+				id, err := app.DB().CreateTaskRelation(ctx, database.CreateTaskRelationParams{
+					ProjectId:  task.ProjectId,
+					FromTaskId: from,
+					ToTaskId:   to,
+					Type:       typ,
+				})
+				if err != nil {
+					if errors.Is(err, database.ErrItemAlreadyExists) {
+						return nil, TaskRelationAlreadyExists()
+					}
+
+					return nil, err
+				}
+
+				return CreateTaskRelation{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteTaskRelation",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/relations/:relationId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeTaskRelationNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				relationId := c.Param("relationId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				relation, err := app.DB().GetTaskRelationById(ctx, relationId)
+				if err != nil {
+					if errors.Is(err, database.ErrItemNotFound) {
+						return nil, TaskRelationNotFound()
+					}
+
+					return nil, err
+				}
+
+				if relation.FromTaskId != task.Id && relation.ToTaskId != task.Id {
+					return nil, TaskRelationNotFound()
+				}
+
+				err = app.DB().DeleteTaskRelation(ctx, relation.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetProjectGraph",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/graph",
+			ResponseType: GetProjectGraph{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				relations, err := app.DB().GetTaskRelationsByProject(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				tasks, err := app.DB().GetTasksByProject(ctx, project.Id, database.TaskFilter{}, database.TaskSortTitle)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): Only the tasks that are part of a relation
+				// is included in the graph
+				used := make(map[string]bool)
+
+				edges := make([]GraphEdge, len(relations))
+				for i, relation := range relations {
+					used[relation.FromTaskId] = true
+					used[relation.ToTaskId] = true
+
+					edges[i] = GraphEdge{
+						From: relation.FromTaskId,
+						To:   relation.ToTaskId,
+						Type: string(relation.Type),
+					}
+				}
+
+				nodes := []GraphNode{}
+				for _, task := range tasks {
+					if !used[task.Id] {
+						continue
+					}
+
+					nodes = append(nodes, GraphNode{
+						Id:        task.Id,
+						Title:     task.Title,
+						BoardId:   task.BoardId,
+						BoardName: task.BoardName,
+						Blocked:   task.BlockerCount > 0,
+					})
+				}
+
+				return GetProjectGraph{
+					Nodes: nodes,
+					Edges: edges,
+					Dot:   generateDot(project.Name, nodes, edges),
+				}, nil
+			},
+		},
+	)
+}
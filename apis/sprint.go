@@ -0,0 +1,671 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/burndown"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type Sprint struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+
+	Start string `json:"start"`
+	End   string `json:"end"`
+
+	Closed *int64 `json:"closed"`
+
+	TaskCount  int64 `json:"taskCount"`
+	TaskDone   int64 `json:"taskDone"`
+	Points     int64 `json:"points"`
+	PointsDone int64 `json:"pointsDone"`
+
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
+
+type GetSprints struct {
+	Sprints []Sprint `json:"sprints"`
+}
+
+type CreateSprint struct {
+	Id string `json:"id"`
+}
+
+type SprintBurndownDay struct {
+	Date string `json:"date"`
+
+	// NOTE(patrik): The points left if the sprint is completed at an even
+	// pace
+	Ideal float64 `json:"ideal"`
+
+	// NOTE(patrik): Null for the days that hasn't happened yet
+	Remaining *int64 `json:"remaining"`
+	Completed *int64 `json:"completed"`
+}
+
+type GetSprintBurndown struct {
+	Points int64               `json:"points"`
+	Days   []SprintBurndownDay `json:"days"`
+}
+
+type CloseSprint struct {
+	NextSprintId *string `json:"nextSprintId"`
+	Moved        int     `json:"moved"`
+}
+
+func ConvertDBSprint(sprint database.Sprint) Sprint {
+	return Sprint{
+		Id:         sprint.Id,
+		Name:       sprint.Name,
+		Start:      utils.FormatDue(sprint.StartDate, false),
+		End:        utils.FormatDue(sprint.EndDate, false),
+		Closed:     ConvertSqlNullInt64(sprint.Closed),
+		TaskCount:  sprint.TaskCount,
+		TaskDone:   sprint.TaskDone,
+		Points:     sprint.Points,
+		PointsDone: sprint.PointsDone,
+		Created:    sprint.Created,
+		Updated:    sprint.Updated,
+	}
+}
+
+type CreateSprintBody struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+func (b *CreateSprintBody) Transform() {
+	b.Name = transform.String(b.Name)
+	b.Start = transform.String(b.Start)
+	b.End = transform.String(b.End)
+}
+
+func (b CreateSprintBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required),
+		validate.Field(&b.Start, validate.Required, validateDate),
+		validate.Field(&b.End, validate.Required, validateDate),
+	)
+}
+
+type EditSprintBody struct {
+	Name  *string `json:"name,omitempty"`
+	Start *string `json:"start,omitempty"`
+	End   *string `json:"end,omitempty"`
+}
+
+func (b *EditSprintBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+	b.Start = transform.StringPtr(b.Start)
+	b.End = transform.StringPtr(b.End)
+}
+
+func (b EditSprintBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.Start, validate.Required.When(b.Start != nil), validateDate),
+		validate.Field(&b.End, validate.Required.When(b.End != nil), validateDate),
+	)
+}
+
+// NOTE(patrik): The body is optional, without a sprint the unfinished tasks
+// is moved to the next open sprint of the project
+type CloseSprintBody struct {
+	NextSprintId string `json:"nextSprintId,omitempty"`
+}
+
+func (b *CloseSprintBody) Transform() {
+	b.NextSprintId = transform.String(b.NextSprintId)
+}
+
+// projectSprint fetches the sprint and makes sure it belongs to the project
+func projectSprint(ctx context.Context, db *database.Database, project database.Project, sprintId string) (database.Sprint, error) {
+	sprint, err := db.GetSprintById(ctx, sprintId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Sprint{}, SprintNotFound()
+		}
+
+		return database.Sprint{}, err
+	}
+
+	if sprint.ProjectId != project.Id {
+		return database.Sprint{}, SprintNotFound()
+	}
+
+	return sprint, nil
+}
+
+func UserSprint(ctx context.Context, app core.App, user *database.User, sprintId string) (database.Sprint, database.Project, error) {
+	sprint, err := app.DB().GetSprintById(ctx, sprintId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Sprint{}, database.Project{}, SprintNotFound()
+		}
+
+		return database.Sprint{}, database.Project{}, err
+	}
+
+	project, err := app.DB().GetProjectById(ctx, sprint.ProjectId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Sprint{}, database.Project{}, SprintNotFound()
+		}
+
+		return database.Sprint{}, database.Project{}, err
+	}
+
+	if project.OwnerId != user.Id {
+		return database.Sprint{}, database.Project{}, SprintNotFound()
+	}
+
+	return sprint, project, nil
+}
+
+// burndownState converts the tracked values of a task into the state used
+// by the burndown
+func burndownState(sprintId, doneBoardId string, values map[string]*string) (burndown.State, error) {
+	var state burndown.State
+
+	if sprint := values[historyFieldSprint]; sprint != nil {
+		state.InSprint = *sprint == sprintId
+	}
+
+	if board := values[historyFieldBoard]; board != nil {
+		state.Done = doneBoardId != "" && *board == doneBoardId
+	}
+
+	if estimate := values[historyFieldEstimate]; estimate != nil {
+		var err error
+		state.Estimate, err = strconv.ParseInt(*estimate, 10, 64)
+		if err != nil {
+			return burndown.State{}, err
+		}
+	}
+
+	return state, nil
+}
+
+// sprintBurndownTasks rebuilds the sprint, board and estimate of the tasks
+// over time from the task history, the history is expected to be sorted
+// with the newest entry first. The history is walked backwards from the
+// current values of the task, the before values of a change is the values
+// the task had until the change.
+func sprintBurndownTasks(sprint database.Sprint, doneBoardId string, tasks []database.Task, history []database.TaskHistory) ([]burndown.Task, error) {
+	entriesByTask := make(map[string][]database.TaskHistory)
+	for _, entry := range history {
+		entriesByTask[entry.TaskId] = append(entriesByTask[entry.TaskId], entry)
+	}
+
+	res := make([]burndown.Task, 0, len(tasks))
+
+	for _, task := range tasks {
+		boardId := task.BoardId
+		values := map[string]*string{
+			historyFieldSprint:   ConvertSqlNullString(task.SprintId),
+			historyFieldBoard:    &boardId,
+			historyFieldEstimate: historyNullInt64(task.Estimate),
+		}
+
+		entries := entriesByTask[task.Id]
+		events := make([]burndown.Event, len(entries)+1)
+
+		for i, entry := range entries {
+			state, err := burndownState(sprint.Id, doneBoardId, values)
+			if err != nil {
+				return nil, err
+			}
+
+			events[len(entries)-i] = burndown.Event{
+				Time:  entry.Created,
+				State: state,
+			}
+
+			changes, err := entry.DecodeChanges()
+			if err != nil {
+				return nil, err
+			}
+
+			for _, change := range changes {
+				if _, tracked := values[change.Field]; tracked {
+					values[change.Field] = change.Before
+				}
+			}
+		}
+
+		// NOTE(patrik): The values before the oldest entry, for tasks with
+		// a created entry the task is outside of the sprint
+		state, err := burndownState(sprint.Id, doneBoardId, values)
+		if err != nil {
+			return nil, err
+		}
+
+		events[0] = burndown.Event{
+			Time:  math.MinInt64,
+			State: state,
+		}
+
+		res = append(res, burndown.Task{Events: events})
+	}
+
+	return res, nil
+}
+
+func ConvertBurndown(res burndown.Result) GetSprintBurndown {
+	days := make([]SprintBurndownDay, len(res.Days))
+	for i, day := range res.Days {
+		days[i] = SprintBurndownDay{
+			Date:      day.Date,
+			Ideal:     day.Ideal,
+			Remaining: day.Remaining,
+			Completed: day.Completed,
+		}
+	}
+
+	return GetSprintBurndown{
+		Points: res.Points,
+		Days:   days,
+	}
+}
+
+func InstallSprintHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetSprints",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/sprints",
+			ResponseType: GetSprints{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				sprints, err := app.DB().GetSprintsByProject(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetSprints{
+					Sprints: make([]Sprint, len(sprints)),
+				}
+
+				for i, sprint := range sprints {
+					res.Sprints[i] = ConvertDBSprint(sprint)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateSprint",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/sprints",
+			ResponseType: CreateSprint{},
+			BodyType:     CreateSprintBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateSprintBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				start := parseDate(body.Start)
+				end := parseDate(body.End)
+
+				if end < start {
+					return nil, pyrin.ValidationError(map[string]string{
+						"end": "must not be before start",
+					})
+				}
+
+				id, err := app.DB().CreateSprint(ctx, database.CreateSprintParams{
+					ProjectId: project.Id,
+					Name:      body.Name,
+					StartDate: start,
+					EndDate:   end,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateSprint{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditSprint",
+			Method:   http.MethodPatch,
+			Path:     "/sprints/:sprintId",
+			BodyType: EditSprintBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeSprintNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				sprintId := c.Param("sprintId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditSprintBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				sprint, _, err := UserSprint(ctx, app, user, sprintId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.SprintChanges{}
+
+				if body.Name != nil {
+					changes.Name = types.Change[string]{
+						Value:   *body.Name,
+						Changed: *body.Name != sprint.Name,
+					}
+				}
+
+				start := sprint.StartDate
+				if body.Start != nil {
+					start = parseDate(*body.Start)
+					changes.StartDate = types.Change[int64]{
+						Value:   start,
+						Changed: start != sprint.StartDate,
+					}
+				}
+
+				end := sprint.EndDate
+				if body.End != nil {
+					end = parseDate(*body.End)
+					changes.EndDate = types.Change[int64]{
+						Value:   end,
+						Changed: end != sprint.EndDate,
+					}
+				}
+
+				if end < start {
+					return nil, pyrin.ValidationError(map[string]string{
+						"end": "must not be before start",
+					})
+				}
+
+				err = app.DB().UpdateSprint(ctx, sprint.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteSprint",
+			Method: http.MethodDelete,
+			Path:   "/sprints/:sprintId",
+			Errors: []pyrin.ErrorType{ErrTypeSprintNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				sprintId := c.Param("sprintId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				sprint, _, err := UserSprint(ctx, app, user, sprintId)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The tasks of the sprint is left without a
+				// sprint
+				err = app.DB().DeleteSprint(ctx, sprint.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetSprintBurndown",
+			Method:       http.MethodGet,
+			Path:         "/sprints/:sprintId/burndown",
+			ResponseType: GetSprintBurndown{},
+			Errors:       []pyrin.ErrorType{ErrTypeSprintNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				sprintId := c.Param("sprintId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				sprint, project, err := UserSprint(ctx, app, user, sprintId)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The tasks that has left the sprint is included
+				// so the chart still shows the scope after the sprint is
+				// closed and the unfinished tasks is moved out
+				tasks, err := app.DB().GetSprintScopeTasks(ctx, sprint.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				doneBoard, err := app.DB().GetDoneBoard(ctx, project.Id)
+				if err != nil && !errors.Is(err, database.ErrItemNotFound) {
+					return nil, err
+				}
+
+				ids := make([]string, len(tasks))
+				for i, task := range tasks {
+					ids[i] = task.Id
+				}
+
+				history, err := app.DB().GetTaskHistoryByTasks(ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+
+				items, err := sprintBurndownTasks(sprint, doneBoard.Id, tasks, history)
+				if err != nil {
+					return nil, err
+				}
+
+				res := burndown.Build(burndown.Sprint{
+					Start:  time.UnixMilli(sprint.StartDate).UTC(),
+					End:    time.UnixMilli(sprint.EndDate).UTC(),
+					Closed: sprint.Closed.Int64,
+				}, items, UserLocation(user), time.Now())
+
+				return ConvertBurndown(res), nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CloseSprint",
+			Method:       http.MethodPost,
+			Path:         "/sprints/:sprintId/close",
+			ResponseType: CloseSprint{},
+			BodyType:     CloseSprintBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeSprintNotFound, ErrTypeSprintClosed},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				sprintId := c.Param("sprintId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CloseSprintBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
+				sprint, project, err := UserSprint(ctx, app, user, sprintId)
+				if err != nil {
+					return nil, err
+				}
+
+				if sprint.Closed.Valid {
+					return nil, SprintClosed()
+				}
+
+				var next database.Sprint
+				if body.NextSprintId != "" {
+					next, err = projectSprint(ctx, app.DB(), project, body.NextSprintId)
+					if err != nil {
+						return nil, err
+					}
+
+					if next.Id == sprint.Id {
+						return nil, SprintNotFound()
+					}
+
+					if next.Closed.Valid {
+						return nil, SprintClosed()
+					}
+				} else {
+					next, err = app.DB().GetNextSprint(ctx, sprint)
+					if err != nil && !errors.Is(err, database.ErrItemNotFound) {
+						return nil, err
+					}
+				}
+
+				// NOTE(patrik): Without a next sprint the unfinished tasks
+				// is removed from the sprint
+				nextId := sql.NullString{
+					String: next.Id,
+					Valid:  next.Id != "",
+				}
+
+				tasks, err := app.DB().GetTasksByProject(ctx, project.Id, database.TaskFilter{
+					Archived: database.TaskArchivedHide,
+					SprintId: sql.NullString{
+						String: sprint.Id,
+						Valid:  true,
+					},
+				}, database.TaskSortRank)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The close time is taken before the tasks is
+				// moved so the burndown counts the moved tasks as unfinished
+				// at the close
+				closed := time.Now().UnixMilli()
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				moved := 0
+				for _, task := range tasks {
+					if task.Done {
+						continue
+					}
+
+					changes := database.TaskChanges{
+						SprintId: types.Change[sql.NullString]{
+							Value:   nextId,
+							Changed: true,
+						},
+					}
+
+					err = recordTaskHistory(ctx, db, user.Id, task, changes, nil)
+					if err != nil {
+						return nil, err
+					}
+
+					err = db.UpdateTask(ctx, task.Id, changes)
+					if err != nil {
+						return nil, err
+					}
+
+					moved++
+				}
+
+				err = db.UpdateSprint(ctx, sprint.Id, database.SprintChanges{
+					Closed: types.Change[sql.NullInt64]{
+						Value: sql.NullInt64{
+							Int64: closed,
+							Valid: true,
+						},
+						Changed: true,
+					},
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return CloseSprint{
+					NextSprintId: ConvertSqlNullString(nextId),
+					Moved:        moved,
+				}, nil
+			},
+		},
+	)
+}
@@ -0,0 +1,197 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+)
+
+type GetTaskChildren struct {
+	Tasks []Task `json:"tasks"`
+}
+
+// isTaskAncestor checks if ancestorId is the task itself or somewhere up
+// the parent chain of the task
+func isTaskAncestor(ctx context.Context, db *database.Database, task database.Task, ancestorId string) (bool, error) {
+	visited := make(map[string]bool)
+
+	for {
+		if task.Id == ancestorId {
+			return true, nil
+		}
+
+		if !task.ParentId.Valid || visited[task.Id] {
+			return false, nil
+		}
+
+		visited[task.Id] = true
+
+		// NOTE(patrik): A parent that can't be found (trashed) ends the
+		// chain, it can't be part of a cycle with a live task
+		parent, err := db.GetTaskById(ctx, task.ParentId.String)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		task = parent
+	}
+}
+
+func InstallSubtaskHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskChildren",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/children",
+			ResponseType: GetTaskChildren{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				children, err := app.DB().GetTaskChildren(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskChildren{
+					Tasks: make([]Task, len(children)),
+				}
+
+				for i, child := range children {
+					res.Tasks[i], err = ConvertDBTask(child)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "AttachTaskChild",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/children/:childId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeProjectNotFound, ErrTypeTaskCycle},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				childId := c.Param("childId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				parent, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				child, _, err := UserTask(ctx, app, user, childId)
+				if err != nil {
+					return nil, err
+				}
+
+				if parent.ProjectId != child.ProjectId {
+					return nil, ProjectNotFound()
+				}
+
+				cycle, err := isTaskAncestor(ctx, app.DB(), parent, child.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				if cycle {
+					return nil, TaskCycle()
+				}
+
+				changes := database.TaskChanges{
+					ParentId: types.Change[sql.NullString]{
+						Value: sql.NullString{
+							String: parent.Id,
+							Valid:  true,
+						},
+						Changed: child.ParentId.String != parent.Id,
+					},
+				}
+
+				err = updateTaskWithHistory(ctx, app, user, child, changes, nil)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DetachTaskChild",
+			Method: http.MethodDelete,
+			Path:   "/tasks/:taskId/children/:childId",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				childId := c.Param("childId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				parent, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				child, _, err := UserTask(ctx, app, user, childId)
+				if err != nil {
+					return nil, err
+				}
+
+				if child.ParentId.String != parent.Id {
+					return nil, TaskNotFound()
+				}
+
+				changes := database.TaskChanges{
+					ParentId: types.Change[sql.NullString]{
+						Value:   sql.NullString{},
+						Changed: true,
+					},
+				}
+
+				err = updateTaskWithHistory(ctx, app, user, child, changes, nil)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
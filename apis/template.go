@@ -0,0 +1,878 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/core/log"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/placeholder"
+	"github.com/nanoteck137/beldum/tools/recurrence"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+const templateChecklistName = "Checklist"
+
+type TaskTemplate struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description *string  `json:"description"`
+	BoardId     *string  `json:"boardId"`
+	Tags        []string `json:"tags"`
+	Checklist   []string `json:"checklist"`
+	Recurrence  *string  `json:"recurrence"`
+
+	// NOTE(patrik): The time the next task is created by the scheduler
+	NextOccurrence *int64 `json:"nextOccurrence"`
+
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
+
+type GetTaskTemplates struct {
+	Templates []TaskTemplate `json:"templates"`
+}
+
+type CreateTaskTemplate struct {
+	Id string `json:"id"`
+}
+
+type CreateTaskFromTemplate struct {
+	Id string `json:"id"`
+}
+
+func decodeTemplateList(s string) ([]string, error) {
+	res := []string{}
+	err := json.Unmarshal([]byte(s), &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func encodeTemplateList(list []string) (string, error) {
+	if list == nil {
+		list = []string{}
+	}
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func ConvertDBTaskTemplate(template database.TaskTemplate) (TaskTemplate, error) {
+	tags, err := decodeTemplateList(template.Tags)
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+
+	checklist, err := decodeTemplateList(template.Checklist)
+	if err != nil {
+		return TaskTemplate{}, err
+	}
+
+	return TaskTemplate{
+		Id:          template.Id,
+		Name:        template.Name,
+		Title:       template.Title,
+		Description: ConvertSqlNullString(template.Description),
+		BoardId:     ConvertSqlNullString(template.BoardId),
+		Tags:        tags,
+		Checklist:   checklist,
+		Recurrence:  ConvertSqlNullString(template.Recurrence),
+
+		NextOccurrence: ConvertSqlNullInt64(template.RecurrenceNext),
+
+		Created: template.Created,
+		Updated: template.Updated,
+	}, nil
+}
+
+var validateChecklistItems = validate.By(func(value interface{}) error {
+	var items []string
+	switch v := value.(type) {
+	case []string:
+		items = v
+	case *[]string:
+		if v != nil {
+			items = *v
+		}
+	}
+
+	return validate.Validate(items, validate.Each(validate.Required))
+})
+
+var validateRecurrence = validate.By(func(value interface{}) error {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case *string:
+		if v != nil {
+			s = *v
+		}
+	}
+
+	if s == "" {
+		return nil
+	}
+
+	_, err := recurrence.Parse(s)
+	if err != nil {
+		return errors.New("invalid recurrence rule")
+	}
+
+	return nil
+})
+
+// templateRecurrence parses the rule and calculates the first occurrence in
+// the time zone of the user, an empty rule removes the recurrence
+func templateRecurrence(user *database.User, s string, now time.Time) (sql.NullString, sql.NullInt64, sql.NullInt64, error) {
+	if s == "" {
+		return sql.NullString{}, sql.NullInt64{}, sql.NullInt64{}, nil
+	}
+
+	rule, err := recurrence.Parse(s)
+	if err != nil {
+		return sql.NullString{}, sql.NullInt64{}, sql.NullInt64{}, err
+	}
+
+	start := now.In(UserLocation(user))
+
+	next := sql.NullInt64{}
+	if t, ok := rule.Next(start, start); ok {
+		next = sql.NullInt64{
+			Int64: t.UnixMilli(),
+			Valid: true,
+		}
+	}
+
+	ruleStr := sql.NullString{
+		String: rule.String(),
+		Valid:  true,
+	}
+
+	startMs := sql.NullInt64{
+		Int64: start.UnixMilli(),
+		Valid: true,
+	}
+
+	return ruleStr, startMs, next, nil
+}
+
+type CreateTaskTemplateBody struct {
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Description string   `json:"description,omitempty"`
+	BoardId     string   `json:"boardId,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Checklist   []string `json:"checklist,omitempty"`
+	Recurrence  string   `json:"recurrence,omitempty"`
+}
+
+func (b *CreateTaskTemplateBody) Transform() {
+	b.Name = transform.String(b.Name)
+	b.Title = transform.String(b.Title)
+	b.Description = transform.String(b.Description)
+	b.BoardId = transform.String(b.BoardId)
+	b.Tags = TransformTags(b.Tags)
+	b.Checklist = transformOptions(b.Checklist)
+	b.Recurrence = transform.String(b.Recurrence)
+}
+
+func (b CreateTaskTemplateBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required),
+		validate.Field(&b.Title, validate.Required),
+		validate.Field(&b.Checklist, validateChecklistItems),
+		validate.Field(&b.Recurrence, validateRecurrence),
+	)
+}
+
+type EditTaskTemplateBody struct {
+	Name        *string   `json:"name,omitempty"`
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	BoardId     *string   `json:"boardId,omitempty"`
+	Tags        *[]string `json:"tags,omitempty"`
+	Checklist   *[]string `json:"checklist,omitempty"`
+	Recurrence  *string   `json:"recurrence,omitempty"`
+}
+
+func (b *EditTaskTemplateBody) Transform() {
+	b.Name = transform.StringPtr(b.Name)
+	b.Title = transform.StringPtr(b.Title)
+	b.Description = transform.StringPtr(b.Description)
+	b.BoardId = transform.StringPtr(b.BoardId)
+	b.Recurrence = transform.StringPtr(b.Recurrence)
+
+	if b.Tags != nil {
+		*b.Tags = TransformTags(*b.Tags)
+	}
+
+	if b.Checklist != nil {
+		*b.Checklist = transformOptions(*b.Checklist)
+	}
+}
+
+func (b EditTaskTemplateBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Name, validate.Required.When(b.Name != nil)),
+		validate.Field(&b.Title, validate.Required.When(b.Title != nil)),
+		validate.Field(&b.Checklist, validateChecklistItems),
+		validate.Field(&b.Recurrence, validateRecurrence),
+	)
+}
+
+// NOTE(patrik): The body is optional, without a board the task is placed on
+// the board of the template
+type CreateTaskFromTemplateBody struct {
+	BoardId string `json:"boardId,omitempty"`
+}
+
+func (b *CreateTaskFromTemplateBody) Transform() {
+	b.BoardId = transform.String(b.BoardId)
+}
+
+// projectBoard fetches the board and makes sure it belongs to the project
+func projectBoard(ctx context.Context, db *database.Database, project database.Project, boardId string) (database.Board, error) {
+	board, err := db.GetBoardById(ctx, boardId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.Board{}, BoardNotFound()
+		}
+
+		return database.Board{}, err
+	}
+
+	if board.ProjectId != project.Id {
+		return database.Board{}, BoardNotFound()
+	}
+
+	return board, nil
+}
+
+// templateBoard picks the board the task is created on, the requested board
+// first then the board of the template and last the first board of the
+// project
+func templateBoard(ctx context.Context, db *database.Database, project database.Project, template database.TaskTemplate, boardId string) (database.Board, error) {
+	if boardId != "" {
+		return projectBoard(ctx, db, project, boardId)
+	}
+
+	if template.BoardId.Valid {
+		board, err := projectBoard(ctx, db, project, template.BoardId.String)
+		if err == nil {
+			return board, nil
+		}
+
+		// NOTE(patrik): The board could be in the trash, fallback to the
+		// first board
+		var e *pyrin.Error
+		if !errors.As(err, &e) || e.Type != ErrTypeBoardNotFound {
+			return database.Board{}, err
+		}
+	}
+
+	boards, err := db.GetBoardsByProject(ctx, project.Id, false)
+	if err != nil {
+		return database.Board{}, err
+	}
+
+	if len(boards) == 0 {
+		return database.Board{}, BoardNotFound()
+	}
+
+	return boards[0], nil
+}
+
+// createTaskFromTemplate creates a new task from the template with the
+// placeholders expanded in the time zone of the user. Should be called
+// inside a transaction.
+func createTaskFromTemplate(ctx context.Context, db *database.Database, user *database.User, project database.Project, template database.TaskTemplate, boardId string, now time.Time) (database.Task, error) {
+	board, err := templateBoard(ctx, db, project, template, boardId)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	tags, err := decodeTemplateList(template.Tags)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	checklist, err := decodeTemplateList(template.Checklist)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	values := placeholder.DateValues(now.In(UserLocation(user)))
+	values["project"] = project.Name
+	values["key"] = project.Key
+
+	description := sql.NullString{}
+	if template.Description.Valid {
+		description = sql.NullString{
+			String: placeholder.Expand(template.Description.String, values),
+			Valid:  true,
+		}
+	}
+
+	task, err := createTask(ctx, db, user, project, database.CreateTaskParams{
+		Title:       placeholder.Expand(template.Title, values),
+		Description: description,
+		BoardId:     board.Id,
+	}, tags)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	if len(checklist) > 0 {
+		checklistId, err := db.CreateTaskChecklist(ctx, database.CreateTaskChecklistParams{
+			TaskId: task.Id,
+			Name:   templateChecklistName,
+		})
+		if err != nil {
+			return database.Task{}, err
+		}
+
+		for i, item := range checklist {
+			_, err := db.CreateTaskChecklistItem(ctx, database.CreateTaskChecklistItemParams{
+				ChecklistId: checklistId,
+				Title:       placeholder.Expand(item, values),
+				OrderNumber: int64(i),
+			})
+			if err != nil {
+				return database.Task{}, err
+			}
+		}
+	}
+
+	return task, nil
+}
+
+// NOTE(patrik): Limits how many missed occurrences is created for a template
+// after the server has been down, the rest is skipped
+const maxRecurrenceCatchUp = 10
+
+func nextOccurrence(rule recurrence.Rule, start, after time.Time) sql.NullInt64 {
+	t, ok := rule.Next(start, after)
+	if !ok {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{
+		Int64: t.UnixMilli(),
+		Valid: true,
+	}
+}
+
+// createOccurrence advances the template to the next occurrence and creates
+// the task in the same transaction so an occurrence is never created twice,
+// returns false if the occurrence was already handled
+func createOccurrence(ctx context.Context, app core.App, owner *database.User, project database.Project, template database.TaskTemplate, current int64, next sql.NullInt64, create bool) (bool, error) {
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	advanced, err := db.AdvanceTaskTemplateRecurrence(ctx, template.Id, current, next)
+	if err != nil {
+		return false, err
+	}
+
+	if !advanced {
+		return false, nil
+	}
+
+	if create {
+		_, err = createTaskFromTemplate(ctx, db, owner, project, template, "", time.UnixMilli(current))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// runRecurringTemplate creates the tasks for the occurrences of the template
+// that is due, the occurrences is calculated in the time zone of the project
+// owner
+func runRecurringTemplate(ctx context.Context, app core.App, template database.TaskTemplate, now time.Time) (int, error) {
+	project, err := app.DB().GetProjectById(ctx, template.ProjectId)
+	if err != nil {
+		return 0, err
+	}
+
+	owner, err := app.DB().GetUserById(ctx, project.OwnerId)
+	if err != nil {
+		return 0, err
+	}
+
+	rule, err := recurrence.Parse(template.Recurrence.String)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.UnixMilli(template.RecurrenceStart.Int64).In(UserLocation(&owner))
+
+	count := 0
+	current := template.RecurrenceNext.Int64
+
+	for current <= now.UnixMilli() {
+		next := nextOccurrence(rule, start, time.UnixMilli(current))
+
+		// NOTE(patrik): Skip the rest of the missed occurrences and move
+		// to the first one after now
+		create := count < maxRecurrenceCatchUp
+		if !create {
+			next = nextOccurrence(rule, start, now)
+		}
+
+		ok, err := createOccurrence(ctx, app, &owner, project, template, current, next, create)
+		if err != nil {
+			return count, err
+		}
+
+		if !ok || !create {
+			break
+		}
+
+		count++
+
+		if !next.Valid {
+			break
+		}
+
+		current = next.Int64
+	}
+
+	return count, nil
+}
+
+// RunRecurringTemplates creates the tasks for all the recurring templates
+// that is due, returns the number of tasks created
+func RunRecurringTemplates(ctx context.Context, app core.App, now time.Time) (int, error) {
+	templates, err := app.DB().GetDueTaskTemplates(ctx, now.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, template := range templates {
+		created, err := runRecurringTemplate(ctx, app, template, now)
+		count += created
+
+		// NOTE(patrik): One broken template should not stop the others
+		if err != nil {
+			log.Error("Failed to run recurring template", "templateId", template.Id, "err", err)
+		}
+	}
+
+	return count, nil
+}
+
+func UserTaskTemplate(ctx context.Context, app core.App, project database.Project, templateId string) (database.TaskTemplate, error) {
+	template, err := app.DB().GetTaskTemplateById(ctx, templateId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.TaskTemplate{}, TemplateNotFound()
+		}
+
+		return database.TaskTemplate{}, err
+	}
+
+	if template.ProjectId != project.Id {
+		return database.TaskTemplate{}, TemplateNotFound()
+	}
+
+	return template, nil
+}
+
+func InstallTemplateHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTaskTemplates",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/templates",
+			ResponseType: GetTaskTemplates{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				templates, err := app.DB().GetTaskTemplates(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskTemplates{
+					Templates: make([]TaskTemplate, len(templates)),
+				}
+
+				for i, template := range templates {
+					res.Templates[i], err = ConvertDBTaskTemplate(template)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateTaskTemplate",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/templates",
+			ResponseType: CreateTaskTemplate{},
+			BodyType:     CreateTaskTemplateBody{},
+			Errors: []pyrin.ErrorType{
+				ErrTypeProjectNotFound,
+				ErrTypeBoardNotFound,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateTaskTemplateBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				boardId := sql.NullString{}
+				if body.BoardId != "" {
+					board, err := projectBoard(ctx, app.DB(), project, body.BoardId)
+					if err != nil {
+						return nil, err
+					}
+
+					boardId = sql.NullString{
+						String: board.Id,
+						Valid:  true,
+					}
+				}
+
+				tags, err := encodeTemplateList(body.Tags)
+				if err != nil {
+					return nil, err
+				}
+
+				checklist, err := encodeTemplateList(body.Checklist)
+				if err != nil {
+					return nil, err
+				}
+
+				rule, start, next, err := templateRecurrence(user, body.Recurrence, time.Now())
+				if err != nil {
+					return nil, err
+				}
+
+				id, err := app.DB().CreateTaskTemplate(ctx, database.CreateTaskTemplateParams{
+					ProjectId: project.Id,
+					BoardId:   boardId,
+					Name:      body.Name,
+					Title:     body.Title,
+					Description: sql.NullString{
+						String: body.Description,
+						Valid:  body.Description != "",
+					},
+					Tags:      tags,
+					Checklist: checklist,
+
+					Recurrence:      rule,
+					RecurrenceStart: start,
+					RecurrenceNext:  next,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateTaskTemplate{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditTaskTemplate",
+			Method:   http.MethodPatch,
+			Path:     "/projects/:projectId/templates/:templateId",
+			BodyType: EditTaskTemplateBody{},
+			Errors: []pyrin.ErrorType{
+				ErrTypeProjectNotFound,
+				ErrTypeTemplateNotFound,
+				ErrTypeBoardNotFound,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+				templateId := c.Param("templateId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditTaskTemplateBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				template, err := UserTaskTemplate(ctx, app, project, templateId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.TaskTemplateChanges{}
+
+				if body.Name != nil {
+					changes.Name = types.Change[string]{
+						Value:   *body.Name,
+						Changed: *body.Name != template.Name,
+					}
+				}
+
+				if body.Title != nil {
+					changes.Title = types.Change[string]{
+						Value:   *body.Title,
+						Changed: *body.Title != template.Title,
+					}
+				}
+
+				if body.Description != nil {
+					changes.Description = types.Change[sql.NullString]{
+						Value: sql.NullString{
+							String: *body.Description,
+							Valid:  *body.Description != "",
+						},
+						Changed: *body.Description != template.Description.String,
+					}
+				}
+
+				// NOTE(patrik): An empty board id removes the board from the
+				// template
+				if body.BoardId != nil {
+					boardId := sql.NullString{}
+					if *body.BoardId != "" {
+						board, err := projectBoard(ctx, app.DB(), project, *body.BoardId)
+						if err != nil {
+							return nil, err
+						}
+
+						boardId = sql.NullString{
+							String: board.Id,
+							Valid:  true,
+						}
+					}
+
+					changes.BoardId = types.Change[sql.NullString]{
+						Value:   boardId,
+						Changed: boardId != template.BoardId,
+					}
+				}
+
+				if body.Tags != nil {
+					tags, err := encodeTemplateList(*body.Tags)
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Tags = types.Change[string]{
+						Value:   tags,
+						Changed: tags != template.Tags,
+					}
+				}
+
+				if body.Checklist != nil {
+					checklist, err := encodeTemplateList(*body.Checklist)
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Checklist = types.Change[string]{
+						Value:   checklist,
+						Changed: checklist != template.Checklist,
+					}
+				}
+
+				// NOTE(patrik): Setting the rule again restarts the recurrence
+				// from now
+				if body.Recurrence != nil {
+					rule, start, next, err := templateRecurrence(user, *body.Recurrence, time.Now())
+					if err != nil {
+						return nil, err
+					}
+
+					changes.Recurrence = types.Change[sql.NullString]{
+						Value:   rule,
+						Changed: true,
+					}
+
+					changes.RecurrenceStart = types.Change[sql.NullInt64]{
+						Value:   start,
+						Changed: true,
+					}
+
+					changes.RecurrenceNext = types.Change[sql.NullInt64]{
+						Value:   next,
+						Changed: true,
+					}
+				}
+
+				err = app.DB().UpdateTaskTemplate(ctx, template.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteTaskTemplate",
+			Method: http.MethodDelete,
+			Path:   "/projects/:projectId/templates/:templateId",
+			Errors: []pyrin.ErrorType{
+				ErrTypeProjectNotFound,
+				ErrTypeTemplateNotFound,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+				templateId := c.Param("templateId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				template, err := UserTaskTemplate(ctx, app, project, templateId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteTaskTemplate(ctx, template.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateTaskFromTemplate",
+			Method:       http.MethodPost,
+			Path:         "/projects/:projectId/templates/:templateId/tasks",
+			ResponseType: CreateTaskFromTemplate{},
+			BodyType:     CreateTaskFromTemplateBody{},
+			Errors: []pyrin.ErrorType{
+				ErrTypeProjectNotFound,
+				ErrTypeTemplateNotFound,
+				ErrTypeBoardNotFound,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+				templateId := c.Param("templateId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateTaskFromTemplateBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				template, err := UserTaskTemplate(ctx, app, project, templateId)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				task, err := createTaskFromTemplate(ctx, db, user, project, template, body.BoardId, time.Now())
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateTaskFromTemplate{
+					Id: task.Id,
+				}, nil
+			},
+		},
+	)
+}
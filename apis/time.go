@@ -0,0 +1,758 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+	"github.com/nanoteck137/validate"
+)
+
+type TimeEntryUser struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+type TimeEntry struct {
+	Id          string        `json:"id"`
+	TaskId      string        `json:"taskId"`
+	User        TimeEntryUser `json:"user"`
+	Description *string       `json:"description"`
+
+	Started int64  `json:"started"`
+	Ended   *int64 `json:"ended"`
+	// NOTE(patrik): Duration in milliseconds, running timers is counted up
+	// to now
+	Duration int64 `json:"duration"`
+
+	Created int64 `json:"created"`
+	Updated int64 `json:"updated"`
+}
+
+type GetTimeEntries struct {
+	Entries []TimeEntry `json:"entries"`
+}
+
+type GetTimer struct {
+	Entry *TimeEntry `json:"entry"`
+}
+
+type StartTimer struct {
+	Id string `json:"id"`
+}
+
+type CreateTimeEntry struct {
+	Id string `json:"id"`
+}
+
+type TimeReportRow struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Duration int64  `json:"duration"`
+}
+
+type TimeReport struct {
+	From    int64           `json:"from"`
+	To      int64           `json:"to"`
+	GroupBy string          `json:"groupBy"`
+	Total   int64           `json:"total"`
+	Rows    []TimeReportRow `json:"rows"`
+}
+
+func ConvertDBTimeEntry(entry database.TimeEntry, now time.Time) TimeEntry {
+	displayName := entry.Username
+	if entry.UserDisplayName.Valid {
+		displayName = entry.UserDisplayName.String
+	}
+
+	ended := now.UnixMilli()
+	if entry.Ended.Valid {
+		ended = entry.Ended.Int64
+	}
+
+	return TimeEntry{
+		Id:     entry.Id,
+		TaskId: entry.TaskId,
+		User: TimeEntryUser{
+			Id:          entry.UserId,
+			DisplayName: displayName,
+		},
+		Description: ConvertSqlNullString(entry.Description),
+		Started:     entry.Started,
+		Ended:       ConvertSqlNullInt64(entry.Ended),
+		Duration:    max(ended-entry.Started, 0),
+		Created:     entry.Created,
+		Updated:     entry.Updated,
+	}
+}
+
+// NOTE(patrik): The body is optional
+type StartTimerBody struct {
+	Description string `json:"description,omitempty"`
+}
+
+func (b *StartTimerBody) Transform() {
+	b.Description = transform.String(b.Description)
+}
+
+type CreateTimeEntryBody struct {
+	Description string `json:"description,omitempty"`
+	Started     int64  `json:"started"`
+	Ended       int64  `json:"ended"`
+}
+
+func (b *CreateTimeEntryBody) Transform() {
+	b.Description = transform.String(b.Description)
+}
+
+func (b CreateTimeEntryBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Started, validate.Required, validate.Min(int64(0))),
+		validate.Field(&b.Ended, validate.Required, validate.Min(b.Started+1).Error("must be after started")),
+	)
+}
+
+type EditTimeEntryBody struct {
+	Description *string `json:"description,omitempty"`
+	Started     *int64  `json:"started,omitempty"`
+	Ended       *int64  `json:"ended,omitempty"`
+}
+
+func (b *EditTimeEntryBody) Transform() {
+	b.Description = transform.StringPtr(b.Description)
+}
+
+func (b EditTimeEntryBody) Validate() error {
+	return validate.ValidateStruct(&b,
+		validate.Field(&b.Started, validate.Min(int64(0))),
+	)
+}
+
+const (
+	timeReportGroupTask  = "task"
+	timeReportGroupTag   = "tag"
+	timeReportGroupBoard = "board"
+	timeReportGroupUser  = "user"
+)
+
+// parseTimeReportRange reads the from and to query parameters, dates without
+// time is interpreted in the users timezone and to includes the whole day.
+// Defaults to the current month.
+func parseTimeReportRange(c pyrin.Context, loc *time.Location, now time.Time) (time.Time, time.Time, error) {
+	query := c.Request().URL.Query()
+
+	now = now.In(loc)
+
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	to := from.AddDate(0, 1, 0)
+
+	parse := func(name string, endOfDay bool) (time.Time, bool, error) {
+		s := query.Get(name)
+		if s == "" {
+			return time.Time{}, false, nil
+		}
+
+		t, hasTime, err := utils.ParseDue(s, loc)
+		if err != nil {
+			return time.Time{}, false, pyrin.ValidationError(map[string]string{
+				name: "invalid date",
+			})
+		}
+
+		if !hasTime {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			if endOfDay {
+				t = t.AddDate(0, 0, 1)
+			}
+		}
+
+		return t, true, nil
+	}
+
+	t, ok, err := parse("from", false)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if ok {
+		from = t
+	}
+
+	t, ok, err = parse("to", true)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if ok {
+		to = t
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, pyrin.ValidationError(map[string]string{
+			"to": "must be after from",
+		})
+	}
+
+	return from, to, nil
+}
+
+// buildTimeReport sums the time of the entries inside the range, the
+// entries is clipped to the range. When grouping by tag the time of a task
+// is counted for every tag on the task.
+func buildTimeReport(ctx context.Context, app core.App, project database.Project, groupBy string, from, to, now time.Time) (TimeReport, error) {
+	entries, err := app.DB().GetProjectTimeEntries(ctx, project.Id, from.UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return TimeReport{}, err
+	}
+
+	tasks, err := app.DB().GetTasksByProject(ctx, project.Id, database.TaskFilter{
+		Archived: database.TaskArchivedInclude,
+	}, database.TaskSortRank)
+	if err != nil {
+		return TimeReport{}, err
+	}
+
+	taskMap := make(map[string]database.Task, len(tasks))
+	for _, task := range tasks {
+		taskMap[task.Id] = task
+	}
+
+	report := TimeReport{
+		From:    from.UnixMilli(),
+		To:      to.UnixMilli(),
+		GroupBy: groupBy,
+		Rows:    []TimeReportRow{},
+	}
+
+	rows := make(map[string]*TimeReportRow)
+	add := func(id, name string, duration int64) {
+		row, exists := rows[id]
+		if !exists {
+			row = &TimeReportRow{
+				Id:   id,
+				Name: name,
+			}
+			rows[id] = row
+		}
+
+		row.Duration += duration
+	}
+
+	for _, entry := range entries {
+		task, exists := taskMap[entry.TaskId]
+		if !exists {
+			continue
+		}
+
+		started := max(entry.Started, from.UnixMilli())
+
+		ended := now.UnixMilli()
+		if entry.Ended.Valid {
+			ended = entry.Ended.Int64
+		}
+		ended = min(ended, to.UnixMilli())
+
+		duration := ended - started
+		if duration <= 0 {
+			continue
+		}
+
+		report.Total += duration
+
+		switch groupBy {
+		case timeReportGroupTask:
+			add(task.Id, utils.FormatTaskKey(task.ProjectKey, task.Number)+" "+task.Title, duration)
+		case timeReportGroupTag:
+			tags := utils.SplitString(task.Tags.String)
+			if len(tags) == 0 {
+				add("", "Untagged", duration)
+			}
+
+			for _, tag := range tags {
+				add(tag, tag, duration)
+			}
+		case timeReportGroupBoard:
+			add(task.BoardId, task.BoardName, duration)
+		case timeReportGroupUser:
+			displayName := entry.Username
+			if entry.UserDisplayName.Valid {
+				displayName = entry.UserDisplayName.String
+			}
+
+			add(entry.UserId, displayName, duration)
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+
+		return a.Name < b.Name
+	})
+
+	return report, nil
+}
+
+// getTimeReport reads the report options from the query and builds the
+// report for the project
+func getTimeReport(ctx context.Context, app core.App, c pyrin.Context) (TimeReport, error) {
+	projectId := c.Param("projectId")
+
+	user, err := User(app, c)
+	if err != nil {
+		return TimeReport{}, err
+	}
+
+	project, err := UserProject(ctx, app, user, projectId)
+	if err != nil {
+		return TimeReport{}, err
+	}
+
+	now := time.Now()
+
+	from, to, err := parseTimeReportRange(c, UserLocation(user), now)
+	if err != nil {
+		return TimeReport{}, err
+	}
+
+	groupBy := c.Request().URL.Query().Get("groupBy")
+	switch groupBy {
+	case "":
+		groupBy = timeReportGroupTask
+	case timeReportGroupTask, timeReportGroupTag, timeReportGroupBoard, timeReportGroupUser:
+	default:
+		return TimeReport{}, pyrin.ValidationError(map[string]string{
+			"groupBy": "invalid value",
+		})
+	}
+
+	return buildTimeReport(ctx, app, project, groupBy, from, to, now)
+}
+
+func UserTimeEntry(ctx context.Context, app core.App, user *database.User, entryId string) (database.TimeEntry, error) {
+	entry, err := app.DB().GetTimeEntryById(ctx, entryId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.TimeEntry{}, TimeEntryNotFound()
+		}
+
+		return database.TimeEntry{}, err
+	}
+
+	if entry.UserId != user.Id {
+		return database.TimeEntry{}, TimeEntryNotFound()
+	}
+
+	return entry, nil
+}
+
+func InstallTimeHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTimer",
+			Method:       http.MethodGet,
+			Path:         "/timer",
+			ResponseType: GetTimer{},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				entry, err := app.DB().GetRunningTimeEntry(ctx, user.Id)
+				if err != nil {
+					if errors.Is(err, database.ErrItemNotFound) {
+						return GetTimer{}, nil
+					}
+
+					return nil, err
+				}
+
+				res := ConvertDBTimeEntry(entry, time.Now())
+
+				return GetTimer{
+					Entry: &res,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "StartTimer",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/timer/start",
+			ResponseType: StartTimer{},
+			BodyType:     StartTimerBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[StartTimerBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				now := time.Now().UnixMilli()
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				// NOTE(patrik): Starting a new timer stops the running one
+				_, err = db.StopRunningTimeEntry(ctx, user.Id, now)
+				if err != nil {
+					return nil, err
+				}
+
+				id, err := db.CreateTimeEntry(ctx, database.CreateTimeEntryParams{
+					TaskId: task.Id,
+					UserId: user.Id,
+					Description: sql.NullString{
+						String: body.Description,
+						Valid:  body.Description != "",
+					},
+					Started: now,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return StartTimer{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "StopTimer",
+			Method: http.MethodPost,
+			Path:   "/timer/stop",
+			Errors: []pyrin.ErrorType{ErrTypeTimerNotRunning},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				stopped, err := app.DB().StopRunningTimeEntry(ctx, user.Id, time.Now().UnixMilli())
+				if err != nil {
+					return nil, err
+				}
+
+				if !stopped {
+					return nil, TimerNotRunning()
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetTaskTimeEntries",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/time-entries",
+			ResponseType: GetTimeEntries{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				entries, err := app.DB().GetTaskTimeEntries(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				now := time.Now()
+
+				res := GetTimeEntries{
+					Entries: make([]TimeEntry, len(entries)),
+				}
+
+				for i, entry := range entries {
+					res.Entries[i] = ConvertDBTimeEntry(entry, now)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "CreateTimeEntry",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/time-entries",
+			ResponseType: CreateTimeEntry{},
+			BodyType:     CreateTimeEntryBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[CreateTimeEntryBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				id, err := app.DB().CreateTimeEntry(ctx, database.CreateTimeEntryParams{
+					TaskId: task.Id,
+					UserId: user.Id,
+					Description: sql.NullString{
+						String: body.Description,
+						Valid:  body.Description != "",
+					},
+					Started: body.Started,
+					Ended: sql.NullInt64{
+						Int64: body.Ended,
+						Valid: true,
+					},
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return CreateTimeEntry{
+					Id: id,
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:     "EditTimeEntry",
+			Method:   http.MethodPatch,
+			Path:     "/time-entries/:entryId",
+			BodyType: EditTimeEntryBody{},
+			Errors:   []pyrin.ErrorType{ErrTypeTimeEntryNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				entryId := c.Param("entryId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				body, err := pyrin.Body[EditTimeEntryBody](c)
+				if err != nil {
+					return nil, err
+				}
+
+				entry, err := UserTimeEntry(ctx, app, user, entryId)
+				if err != nil {
+					return nil, err
+				}
+
+				changes := database.TimeEntryChanges{}
+
+				if body.Description != nil {
+					changes.Description = types.Change[sql.NullString]{
+						Value: sql.NullString{
+							String: *body.Description,
+							Valid:  *body.Description != "",
+						},
+						Changed: *body.Description != entry.Description.String,
+					}
+				}
+
+				started := entry.Started
+				if body.Started != nil {
+					started = *body.Started
+					changes.Started = types.Change[int64]{
+						Value:   started,
+						Changed: started != entry.Started,
+					}
+				}
+
+				// NOTE(patrik): Setting the end of a running entry stops the
+				// timer
+				ended := entry.Ended
+				if body.Ended != nil {
+					ended = sql.NullInt64{
+						Int64: *body.Ended,
+						Valid: true,
+					}
+					changes.Ended = types.Change[sql.NullInt64]{
+						Value:   ended,
+						Changed: ended != entry.Ended,
+					}
+				}
+
+				if ended.Valid && ended.Int64 <= started {
+					return nil, pyrin.ValidationError(map[string]string{
+						"ended": "must be after started",
+					})
+				}
+
+				err = app.DB().UpdateTimeEntry(ctx, entry.Id, changes)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteTimeEntry",
+			Method: http.MethodDelete,
+			Path:   "/time-entries/:entryId",
+			Errors: []pyrin.ErrorType{ErrTypeTimeEntryNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				entryId := c.Param("entryId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				entry, err := UserTimeEntry(ctx, app, user, entryId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = app.DB().DeleteTimeEntry(ctx, entry.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetTimeReport",
+			Method:       http.MethodGet,
+			Path:         "/projects/:projectId/time-report",
+			ResponseType: TimeReport{},
+			Errors:       []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				report, err := getTimeReport(ctx, app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				return report, nil
+			},
+		},
+
+		pyrin.NormalHandler{
+			Name:   "GetTimeReportCsv",
+			Method: http.MethodGet,
+			Path:   "/projects/:projectId/time-report/csv",
+			HandlerFunc: func(c pyrin.Context) error {
+				ctx := context.TODO()
+
+				report, err := getTimeReport(ctx, app, c)
+				if err != nil {
+					return err
+				}
+
+				h := c.Response().Header()
+				h.Set("Content-Type", "text/csv; charset=utf-8")
+				h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+					"filename": "time-report.csv",
+				}))
+
+				w := csv.NewWriter(c.Response())
+
+				err = w.Write([]string{report.GroupBy, "id", "seconds", "hours"})
+				if err != nil {
+					return err
+				}
+
+				hours := func(ms int64) string {
+					return strconv.FormatFloat(float64(ms)/float64(time.Hour.Milliseconds()), 'f', 2, 64)
+				}
+
+				for _, row := range report.Rows {
+					err := w.Write([]string{
+						row.Name,
+						row.Id,
+						strconv.FormatInt(row.Duration/1000, 10),
+						hours(row.Duration),
+					})
+					if err != nil {
+						return err
+					}
+				}
+
+				err = w.Write([]string{"Total", "", strconv.FormatInt(report.Total/1000, 10), hours(report.Total)})
+				if err != nil {
+					return err
+				}
+
+				w.Flush()
+
+				return w.Error()
+			},
+		},
+	)
+}
@@ -0,0 +1,531 @@
+package apis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+	"github.com/nanoteck137/pyrin/tools/transform"
+)
+
+type TransferTask struct {
+	Id  string `json:"id"`
+	Key string `json:"key"`
+}
+
+type MoveTaskToProjectBody struct {
+	// NOTE(patrik): Empty picks the board with the same name as the
+	// current board, or the first board of the project
+	BoardId string `json:"boardId,omitempty"`
+}
+
+func (b *MoveTaskToProjectBody) Transform() {
+	b.BoardId = transform.String(b.BoardId)
+}
+
+type DuplicateTaskBody struct {
+	// NOTE(patrik): Empty duplicates the task inside the same project
+	ProjectId string `json:"projectId,omitempty"`
+	BoardId   string `json:"boardId,omitempty"`
+}
+
+func (b *DuplicateTaskBody) Transform() {
+	b.ProjectId = transform.String(b.ProjectId)
+	b.BoardId = transform.String(b.BoardId)
+}
+
+type taskFieldValue struct {
+	FieldId string
+	Value   string
+}
+
+// transferBoard picks the board inside the project the task is sent to,
+// without a board id the board with the same name is used and if there is
+// no such board the fallback is used
+func transferBoard(boards []database.Board, boardId, name string, fallback database.Board) (database.Board, error) {
+	if boardId != "" {
+		index := slices.IndexFunc(boards, func(board database.Board) bool {
+			return board.Id == boardId
+		})
+		if index == -1 {
+			return database.Board{}, BoardNotFound()
+		}
+
+		return boards[index], nil
+	}
+
+	index := slices.IndexFunc(boards, func(board database.Board) bool {
+		return strings.EqualFold(board.Name, name)
+	})
+	if index != -1 {
+		return boards[index], nil
+	}
+
+	return fallback, nil
+}
+
+// remapTaskFields maps the custom field values of the task to the fields of
+// another project, fields is matched by name and type and select options
+// missing from the target field is dropped
+func remapTaskFields(task database.Task, targets []database.ProjectField) ([]taskFieldValue, error) {
+	items, err := decodeTaskFields(task.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []taskFieldValue
+	for _, item := range items {
+		index := slices.IndexFunc(targets, func(field database.ProjectField) bool {
+			return field.Type == item.Type && strings.EqualFold(field.Name, item.Name)
+		})
+		if index == -1 {
+			continue
+		}
+
+		field := targets[index]
+		value := item.Value
+
+		switch field.Type {
+		case types.FieldTypeSingleSelect:
+			if !slices.Contains(fieldOptions(field), value) {
+				continue
+			}
+		case types.FieldTypeMultiSelect:
+			var selected []string
+			err := json.Unmarshal([]byte(value), &selected)
+			if err != nil {
+				continue
+			}
+
+			options := fieldOptions(field)
+			selected = slices.DeleteFunc(selected, func(option string) bool {
+				return !slices.Contains(options, option)
+			})
+			if len(selected) == 0 {
+				continue
+			}
+
+			data, err := json.Marshal(selected)
+			if err != nil {
+				return nil, err
+			}
+
+			value = string(data)
+		}
+
+		res = append(res, taskFieldValue{
+			FieldId: field.Id,
+			Value:   value,
+		})
+	}
+
+	return res, nil
+}
+
+// taskSubtree returns the task followed by all the tasks below it, trashed
+// tasks is not included
+func taskSubtree(ctx context.Context, db *database.Database, task database.Task) ([]database.Task, error) {
+	res := []database.Task{task}
+
+	for i := 0; i < len(res); i++ {
+		children, err := db.GetTaskChildren(ctx, res[i].Id)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, children...)
+	}
+
+	return res, nil
+}
+
+// moveTaskToProject moves a single task to the project, the task gets a new
+// number inside the project and the tags is recreated inside the project.
+// Sprints and milestones belongs to the old project so they are cleared.
+// Relations is handled by the caller for the whole subtree, relations
+// inside the subtree is moved with the tasks and relations to tasks that
+// stays behind is removed.
+func moveTaskToProject(ctx context.Context, db *database.Database, userId string, task database.Task, project database.Project, board database.Board, fields []database.ProjectField, detach bool) error {
+	number, err := db.NextTaskNumber(ctx, project.Id)
+	if err != nil {
+		return err
+	}
+
+	taskRank, err := db.LastTaskRank(ctx, board.Id)
+	if err != nil {
+		return err
+	}
+
+	values, err := remapTaskFields(task, fields)
+	if err != nil {
+		return err
+	}
+
+	changes := database.TaskChanges{
+		Number: types.Change[int64]{
+			Value:   number,
+			Changed: true,
+		},
+		ProjectId: types.Change[string]{
+			Value:   project.Id,
+			Changed: true,
+		},
+		BoardId: types.Change[string]{
+			Value:   board.Id,
+			Changed: true,
+		},
+		Rank: types.Change[string]{
+			Value:   taskRank,
+			Changed: true,
+		},
+		ParentId: types.Change[sql.NullString]{
+			Changed: detach && task.ParentId.Valid,
+		},
+		SprintId: types.Change[sql.NullString]{
+			Changed: task.SprintId.Valid,
+		},
+		MilestoneId: types.Change[sql.NullString]{
+			Changed: task.MilestoneId.Valid,
+		},
+	}
+
+	// NOTE(patrik): The task keeps the id so the history, comments,
+	// attachments and time entries stays with the task
+	err = recordTaskHistory(ctx, db, userId, task, changes, nil)
+	if err != nil {
+		return err
+	}
+
+	err = db.RemoveAllTaskTags(ctx, task.Id)
+	if err != nil {
+		return err
+	}
+
+	err = db.RemoveAllTaskFieldValues(ctx, task.Id)
+	if err != nil {
+		return err
+	}
+
+	err = db.UpdateTask(ctx, task.Id, changes)
+	if err != nil {
+		return err
+	}
+
+	tags := utils.SplitString(task.Tags.String)
+	err = updateTaskTags(ctx, db, project.Id, task.Id, nil, tags)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		err := db.SetTaskFieldValue(ctx, task.Id, value.FieldId, value.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// duplicateTask creates a copy of the task inside the project together with
+// the tags, custom field values and checklists, the copy starts with its
+// own history
+func duplicateTask(ctx context.Context, db *database.Database, user *database.User, task database.Task, project database.Project, board database.Board) (database.Task, error) {
+	params := database.CreateTaskParams{
+		Title:       task.Title,
+		Description: task.Description,
+		BoardId:     board.Id,
+		Due:         task.Due,
+		DueHasTime:  task.DueHasTime,
+		Priority:    task.Priority,
+		Estimate:    task.Estimate,
+	}
+
+	sameProject := project.Id == task.ProjectId
+	if sameProject {
+		params.ParentId = task.ParentId
+		params.SprintId = task.SprintId
+		params.MilestoneId = task.MilestoneId
+	}
+
+	fields, err := db.GetProjectFields(ctx, project.Id)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	values, err := remapTaskFields(task, fields)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	tags := utils.SplitString(task.Tags.String)
+	res, err := createTask(ctx, db, user, project, params, tags)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	for _, value := range values {
+		err := db.SetTaskFieldValue(ctx, res.Id, value.FieldId, value.Value)
+		if err != nil {
+			return database.Task{}, err
+		}
+	}
+
+	checklists, err := db.GetTaskChecklists(ctx, task.Id)
+	if err != nil {
+		return database.Task{}, err
+	}
+
+	for _, checklist := range checklists {
+		checklistId, err := db.CreateTaskChecklist(ctx, database.CreateTaskChecklistParams{
+			TaskId:      res.Id,
+			Name:        checklist.Name,
+			OrderNumber: checklist.OrderNumber,
+		})
+		if err != nil {
+			return database.Task{}, err
+		}
+
+		items, err := db.GetTaskChecklistItems(ctx, checklist.Id)
+		if err != nil {
+			return database.Task{}, err
+		}
+
+		// NOTE(patrik): The copy is new work so the items starts unchecked
+		for _, item := range items {
+			_, err := db.CreateTaskChecklistItem(ctx, database.CreateTaskChecklistItemParams{
+				ChecklistId: checklistId,
+				Title:       item.Title,
+				OrderNumber: item.OrderNumber,
+			})
+			if err != nil {
+				return database.Task{}, err
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func InstallTransferHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "MoveTaskToProject",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/move-to-project/:projectId",
+			ResponseType: TransferTask{},
+			BodyType:     MoveTaskToProjectBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeProjectNotFound, ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The body is optional
+				body, err := pyrin.Body[MoveTaskToProjectBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				if project.Id == task.ProjectId {
+					return nil, pyrin.ValidationError(map[string]string{
+						"projectId": "task is already in the project",
+					})
+				}
+
+				boards, err := app.DB().GetBoardsByProject(ctx, project.Id, false)
+				if err != nil {
+					return nil, err
+				}
+
+				if len(boards) == 0 {
+					return nil, BoardNotFound()
+				}
+
+				board, err := transferBoard(boards, body.BoardId, task.BoardName, boards[0])
+				if err != nil {
+					return nil, err
+				}
+
+				fields, err := app.DB().GetProjectFields(ctx, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): Subtasks needs to be in the same project as
+				// the parent so they are moved together with the task
+				tasks, err := taskSubtree(ctx, app.DB(), task)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				for i, t := range tasks {
+					b := board
+					if i > 0 {
+						b, err = transferBoard(boards, "", t.BoardName, board)
+						if err != nil {
+							return nil, err
+						}
+					}
+
+					err := moveTaskToProject(ctx, db, user.Id, t, project, b, fields, i == 0)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				ids := make([]string, len(tasks))
+				for i, t := range tasks {
+					ids[i] = t.Id
+				}
+
+				// NOTE(patrik): Trashed subtasks stays in the old project so
+				// they can't keep the moved task as the parent
+				err = db.DetachTrashedChildren(ctx, ids)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): Relations can't cross projects
+				err = db.MoveTaskRelations(ctx, ids, project.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				task, err = app.DB().GetTaskById(ctx, task.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				return TransferTask{
+					Id:  task.Id,
+					Key: utils.FormatTaskKey(task.ProjectKey, task.Number),
+				}, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "DuplicateTask",
+			Method:       http.MethodPost,
+			Path:         "/tasks/:taskId/duplicate",
+			ResponseType: TransferTask{},
+			BodyType:     DuplicateTaskBody{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound, ErrTypeProjectNotFound, ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): The body is optional
+				body, err := pyrin.Body[DuplicateTaskBody](c)
+				if err != nil {
+					var e *pyrin.Error
+					if !errors.As(err, &e) || e.Type != pyrin.ErrTypeEmptyBody {
+						return nil, err
+					}
+				}
+
+				task, project, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				if body.ProjectId != "" && body.ProjectId != project.Id {
+					project, err = UserProject(ctx, app, user, body.ProjectId)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				boards, err := app.DB().GetBoardsByProject(ctx, project.Id, false)
+				if err != nil {
+					return nil, err
+				}
+
+				fallback := database.Board{}
+				if project.Id == task.ProjectId {
+					fallback, err = app.DB().GetBoardById(ctx, task.BoardId)
+					if err != nil {
+						return nil, err
+					}
+				} else {
+					if len(boards) == 0 {
+						return nil, BoardNotFound()
+					}
+
+					fallback = boards[0]
+				}
+
+				board, err := transferBoard(boards, body.BoardId, task.BoardName, fallback)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				res, err := duplicateTask(ctx, db, user, task, project, board)
+				if err != nil {
+					return nil, err
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				return TransferTask{
+					Id:  res.Id,
+					Key: utils.FormatTaskKey(project.Key, res.Number),
+				}, nil
+			},
+		},
+	)
+}
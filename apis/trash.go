@@ -0,0 +1,453 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/core/log"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/types"
+	"github.com/nanoteck137/pyrin"
+)
+
+type TrashItem struct {
+	Id     string          `json:"id"`
+	Type   types.TrashType `json:"type"`
+	ItemId string          `json:"itemId"`
+	Name   string          `json:"name"`
+
+	ProjectId   string `json:"projectId"`
+	ProjectName string `json:"projectName"`
+
+	Deleted int64 `json:"deleted"`
+	// NOTE(patrik): Nil when the retention is disabled
+	Expires *int64 `json:"expires"`
+}
+
+type GetTrash struct {
+	Items []TrashItem `json:"items"`
+}
+
+func ConvertDBTrashItem(app core.App, item database.TrashItem) TrashItem {
+	var expires *int64
+	if days := app.Config().TrashRetentionDays; days > 0 {
+		t := time.UnixMilli(item.Created).AddDate(0, 0, days).UnixMilli()
+		expires = &t
+	}
+
+	return TrashItem{
+		Id:          item.Id,
+		Type:        item.Type,
+		ItemId:      item.ItemId,
+		Name:        item.Name,
+		ProjectId:   item.ProjectId,
+		ProjectName: item.ProjectName,
+		Deleted:     item.Created,
+		Expires:     expires,
+	}
+}
+
+// MoveToTrash moves the item and everything below it into the trash
+func MoveToTrash(ctx context.Context, app core.App, params database.CreateTrashItemParams) error {
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = db.CreateTrashItem(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// purgeTrashItem purges the item inside the transaction, returns the ids of
+// the removed trash entries and the files that should be removed after the
+// transaction is committed
+func purgeTrashItem(ctx context.Context, app core.App, db *database.Database, item database.TrashItem) ([]string, []string, error) {
+	attachments, err := db.GetAttachmentIdsByTrashItem(ctx, item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed, err := db.PurgeTrashItem(ctx, item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []string
+
+	for _, id := range attachments {
+		files = append(files, path.Join(app.WorkDir().Attachments(), id))
+	}
+
+	for _, id := range removed {
+		files = append(files, path.Join(app.WorkDir().Trash(), id))
+	}
+
+	return removed, files, nil
+}
+
+func removeFiles(files []string) {
+	for _, file := range files {
+		err := os.RemoveAll(file)
+		if err != nil {
+			log.Error("Failed to remove file", "file", file, "err", err)
+		}
+	}
+}
+
+// PurgeTrashItem permanently deletes the item from the trash
+func PurgeTrashItem(ctx context.Context, app core.App, item database.TrashItem) error {
+	db, tx, err := app.DB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, files, err := purgeTrashItem(ctx, app, db, item)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	removeFiles(files)
+
+	return nil
+}
+
+// PurgeExpiredTrash purges every item that has been in the trash longer than
+// the configured retention, returns the number of purged items
+func PurgeExpiredTrash(ctx context.Context, app core.App) (int, error) {
+	days := app.Config().TrashRetentionDays
+	if days <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().AddDate(0, 0, -days).UnixMilli()
+
+	items, err := app.DB().GetExpiredTrashItems(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, item := range items {
+		// NOTE(patrik): The item could already be gone if it was inside
+		// another item that was purged before it
+		_, err := app.DB().GetTrashItemById(ctx, item.Id)
+		if err != nil {
+			if errors.Is(err, database.ErrItemNotFound) {
+				continue
+			}
+
+			return count, err
+		}
+
+		err = PurgeTrashItem(ctx, app, item)
+		if err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, nil
+}
+
+func UserTrashItem(ctx context.Context, app core.App, user *database.User, trashId string) (database.TrashItem, error) {
+	item, err := app.DB().GetTrashItemById(ctx, trashId)
+	if err != nil {
+		if errors.Is(err, database.ErrItemNotFound) {
+			return database.TrashItem{}, TrashItemNotFound()
+		}
+
+		return database.TrashItem{}, err
+	}
+
+	if item.OwnerId != user.Id {
+		return database.TrashItem{}, TrashItemNotFound()
+	}
+
+	return item, nil
+}
+
+func InstallTrashHandlers(app core.App, group pyrin.Group) {
+	group.Register(
+		pyrin.ApiHandler{
+			Name:         "GetTrash",
+			Method:       http.MethodGet,
+			Path:         "/trash",
+			ResponseType: GetTrash{},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				items, err := app.DB().GetTrashItemsByOwner(ctx, user.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTrash{
+					Items: make([]TrashItem, len(items)),
+				}
+
+				for i, item := range items {
+					res.Items[i] = ConvertDBTrashItem(app, item)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "RestoreTrashItem",
+			Method: http.MethodPost,
+			Path:   "/trash/:trashId/restore",
+			Errors: []pyrin.ErrorType{
+				ErrTypeTrashItemNotFound,
+				ErrTypeTrashParentTrashed,
+			},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				trashId := c.Param("trashId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				item, err := UserTrashItem(ctx, app, user, trashId)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				trashed, err := db.IsTrashItemParentTrashed(ctx, item)
+				if err != nil {
+					return nil, err
+				}
+
+				if trashed {
+					return nil, TrashParentTrashed()
+				}
+
+				err = db.RestoreTrashItem(ctx, item.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				// NOTE(patrik): Moved back into the trash if the commit fails
+				src := ""
+				dst := ""
+				if item.Type == types.TrashTypeAttachment {
+					src = path.Join(app.WorkDir().Trash(), item.Id)
+					dst = path.Join(app.WorkDir().Attachments(), item.ItemId)
+
+					err = os.Rename(src, dst)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					if dst != "" {
+						os.Rename(dst, src)
+					}
+
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "PurgeTrashItem",
+			Method: http.MethodDelete,
+			Path:   "/trash/:trashId",
+			Errors: []pyrin.ErrorType{ErrTypeTrashItemNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				trashId := c.Param("trashId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				item, err := UserTrashItem(ctx, app, user, trashId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = PurgeTrashItem(ctx, app, item)
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "EmptyTrash",
+			Method: http.MethodDelete,
+			Path:   "/trash",
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				db, tx, err := app.DB().Begin()
+				if err != nil {
+					return nil, err
+				}
+				defer tx.Rollback()
+
+				items, err := db.GetTrashItemsByOwner(ctx, user.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				var files []string
+
+				purged := make(map[string]bool)
+				for _, item := range items {
+					if purged[item.Id] {
+						continue
+					}
+
+					removed, f, err := purgeTrashItem(ctx, app, db, item)
+					if err != nil {
+						return nil, err
+					}
+
+					files = append(files, f...)
+
+					for _, id := range removed {
+						purged[id] = true
+					}
+				}
+
+				err = tx.Commit()
+				if err != nil {
+					return nil, err
+				}
+
+				removeFiles(files)
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteProject",
+			Method: http.MethodDelete,
+			Path:   "/projects/:projectId",
+			Errors: []pyrin.ErrorType{ErrTypeProjectNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				projectId := c.Param("projectId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				project, err := UserProject(ctx, app, user, projectId)
+				if err != nil {
+					return nil, err
+				}
+
+				err = MoveToTrash(ctx, app, database.CreateTrashItemParams{
+					OwnerId:   user.Id,
+					ProjectId: project.Id,
+					Type:      types.TrashTypeProject,
+					ItemId:    project.Id,
+					Name:      project.Name,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "DeleteBoard",
+			Method: http.MethodDelete,
+			Path:   "/boards/:boardId",
+			Errors: []pyrin.ErrorType{ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				boardId := c.Param("boardId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				board, err := app.DB().GetBoardById(ctx, boardId)
+				if err != nil {
+					if errors.Is(err, database.ErrItemNotFound) {
+						return nil, BoardNotFound()
+					}
+
+					return nil, err
+				}
+
+				project, err := app.DB().GetProjectById(ctx, board.ProjectId)
+				if err != nil {
+					return nil, err
+				}
+
+				if project.OwnerId != user.Id {
+					return nil, BoardNotFound()
+				}
+
+				err = MoveToTrash(ctx, app, database.CreateTrashItemParams{
+					OwnerId:   user.Id,
+					ProjectId: project.Id,
+					Type:      types.TrashTypeBoard,
+					ItemId:    board.Id,
+					Name:      board.Name,
+				})
+				if err != nil {
+					return nil, err
+				}
+
+				return nil, nil
+			},
+		},
+	)
+}
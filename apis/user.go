@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/nanoteck137/beldum/core"
 	"github.com/nanoteck137/beldum/database"
@@ -16,20 +17,35 @@ import (
 type UpdateUserSettingsBody struct {
 	DisplayName   *string `json:"displayName,omitempty"`
 	QuickPlaylist *string `json:"quickPlaylist,omitempty"`
+	Timezone      *string `json:"timezone,omitempty"`
 }
 
 func (b *UpdateUserSettingsBody) Transform() {
 	b.DisplayName = transform.StringPtr(b.DisplayName)
 	b.QuickPlaylist = transform.StringPtr(b.QuickPlaylist)
+	b.Timezone = transform.StringPtr(b.Timezone)
 }
 
 func (b UpdateUserSettingsBody) Validate() error {
+	checkTimezone := validate.By(func(value interface{}) error {
+		if b.Timezone == nil || *b.Timezone == "" {
+			return nil
+		}
+
+		_, err := time.LoadLocation(*b.Timezone)
+		if err != nil {
+			return errors.New("unknown timezone")
+		}
+
+		return nil
+	})
+
 	return validate.ValidateStruct(&b,
 		validate.Field(&b.DisplayName,
 			validate.Required.When(b.DisplayName != nil),
 		),
 		validate.Field(&b.QuickPlaylist), // validate.Required.When(b.QuickPlaylist != nil),
-
+		validate.Field(&b.Timezone, checkTimezone),
 	)
 }
 
@@ -99,6 +115,13 @@ func InstallUserHandlers(app core.App, group pyrin.Group) {
 					}
 				}
 
+				if body.Timezone != nil {
+					settings.Timezone = sql.NullString{
+						String: *body.Timezone,
+						Valid:  *body.Timezone != "",
+					}
+				}
+
 				err = app.DB().UpdateUserSettings(context.TODO(), settings)
 				if err != nil {
 					// TODO(patrik): Handle error
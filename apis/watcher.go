@@ -0,0 +1,232 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/nanoteck137/beldum/core"
+	"github.com/nanoteck137/beldum/database"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/pyrin"
+)
+
+type TaskWatcher struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+
+	// NOTE(patrik): "task" when watching the task directly or "board" when
+	// watching the board the task is on
+	Via string `json:"via"`
+}
+
+type GetTaskWatchers struct {
+	Watchers []TaskWatcher `json:"watchers"`
+}
+
+type TaskSubscription struct {
+	TaskId    string `json:"taskId"`
+	TaskKey   string `json:"taskKey"`
+	TaskTitle string `json:"taskTitle"`
+	ProjectId string `json:"projectId"`
+	Created   int64  `json:"created"`
+}
+
+type BoardSubscription struct {
+	BoardId   string `json:"boardId"`
+	BoardName string `json:"boardName"`
+	ProjectId string `json:"projectId"`
+	Created   int64  `json:"created"`
+}
+
+type GetUserSubscriptions struct {
+	Tasks  []TaskSubscription  `json:"tasks"`
+	Boards []BoardSubscription `json:"boards"`
+}
+
+func ConvertDBTaskWatcher(item database.TaskWatcher) TaskWatcher {
+	displayName := item.Username
+	if item.UserDisplayName.Valid {
+		displayName = item.UserDisplayName.String
+	}
+
+	return TaskWatcher{
+		Id:          item.UserId,
+		DisplayName: displayName,
+		Via:         item.Via,
+	}
+}
+
+func InstallWatcherHandlers(app core.App, group pyrin.Group) {
+	setTaskWatching := func(c pyrin.Context, watching bool) error {
+		taskId := c.Param("taskId")
+
+		ctx := context.TODO()
+
+		user, err := User(app, c)
+		if err != nil {
+			return err
+		}
+
+		task, _, err := UserTask(ctx, app, user, taskId)
+		if err != nil {
+			return err
+		}
+
+		// NOTE(patrik): Unwatching is stored so the user isn't subscribed
+		// again by commenting or through the board
+		return app.DB().SetTaskWatching(ctx, task.Id, user.Id, watching)
+	}
+
+	setBoardWatching := func(c pyrin.Context, watching bool) error {
+		boardId := c.Param("boardId")
+
+		ctx := context.TODO()
+
+		user, err := User(app, c)
+		if err != nil {
+			return err
+		}
+
+		board, err := UserBoard(ctx, app, user, boardId)
+		if err != nil {
+			return err
+		}
+
+		if watching {
+			return app.DB().AddBoardWatcher(ctx, board.Id, user.Id)
+		}
+
+		return app.DB().RemoveBoardWatcher(ctx, board.Id, user.Id)
+	}
+
+	group.Register(
+		pyrin.ApiHandler{
+			Name:   "WatchTask",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/watch",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setTaskWatching(c, true)
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "UnwatchTask",
+			Method: http.MethodPost,
+			Path:   "/tasks/:taskId/unwatch",
+			Errors: []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setTaskWatching(c, false)
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetTaskWatchers",
+			Method:       http.MethodGet,
+			Path:         "/tasks/:taskId/watchers",
+			ResponseType: GetTaskWatchers{},
+			Errors:       []pyrin.ErrorType{ErrTypeTaskNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				taskId := c.Param("taskId")
+
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				task, _, err := UserTask(ctx, app, user, taskId)
+				if err != nil {
+					return nil, err
+				}
+
+				watchers, err := app.DB().GetTaskWatchers(ctx, task)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetTaskWatchers{
+					Watchers: make([]TaskWatcher, len(watchers)),
+				}
+
+				for i, watcher := range watchers {
+					res.Watchers[i] = ConvertDBTaskWatcher(watcher)
+				}
+
+				return res, nil
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "WatchBoard",
+			Method: http.MethodPost,
+			Path:   "/boards/:boardId/watch",
+			Errors: []pyrin.ErrorType{ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setBoardWatching(c, true)
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:   "UnwatchBoard",
+			Method: http.MethodPost,
+			Path:   "/boards/:boardId/unwatch",
+			Errors: []pyrin.ErrorType{ErrTypeBoardNotFound},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				return nil, setBoardWatching(c, false)
+			},
+		},
+
+		pyrin.ApiHandler{
+			Name:         "GetUserSubscriptions",
+			Method:       http.MethodGet,
+			Path:         "/user/subscriptions",
+			ResponseType: GetUserSubscriptions{},
+			HandlerFunc: func(c pyrin.Context) (any, error) {
+				ctx := context.TODO()
+
+				user, err := User(app, c)
+				if err != nil {
+					return nil, err
+				}
+
+				tasks, err := app.DB().GetUserTaskSubscriptions(ctx, user.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				boards, err := app.DB().GetUserBoardSubscriptions(ctx, user.Id)
+				if err != nil {
+					return nil, err
+				}
+
+				res := GetUserSubscriptions{
+					Tasks:  make([]TaskSubscription, len(tasks)),
+					Boards: make([]BoardSubscription, len(boards)),
+				}
+
+				for i, task := range tasks {
+					res.Tasks[i] = TaskSubscription{
+						TaskId:    task.TaskId,
+						TaskKey:   utils.FormatTaskKey(task.ProjectKey, task.TaskNumber),
+						TaskTitle: task.TaskTitle,
+						ProjectId: task.ProjectId,
+						Created:   task.Created,
+					}
+				}
+
+				for i, board := range boards {
+					res.Boards[i] = BoardSubscription{
+						BoardId:   board.BoardId,
+						BoardName: board.BoardName,
+						ProjectId: board.ProjectId,
+						Created:   board.Created,
+					}
+				}
+
+				return res, nil
+			},
+		},
+	)
+}
@@ -1,6 +1,11 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
 	"github.com/nanoteck137/beldum/apis"
 	"github.com/nanoteck137/beldum/config"
 	"github.com/nanoteck137/beldum/core"
@@ -8,6 +13,88 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	maintenanceInterval = time.Hour
+	schedulerInterval   = time.Minute
+)
+
+// runMaintenance archives the tasks that has been on the done board for too
+// long and purges expired items from the trash, runs once on startup and
+// then on every interval
+func runMaintenance(app core.App) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx := context.Background()
+
+		count, err := apis.AutoArchiveTasks(ctx, app)
+		if err != nil {
+			log.Error("Failed to auto archive tasks", "err", err)
+		} else if count > 0 {
+			log.Info("Auto archived tasks", "count", count)
+		}
+
+		purged, err := apis.PurgeExpiredTrash(ctx, app)
+		if err != nil {
+			log.Error("Failed to purge expired trash", "err", err)
+		} else if purged > 0 {
+			log.Info("Purged expired trash", "count", purged)
+		}
+
+		<-ticker.C
+	}
+}
+
+// runScheduler creates the tasks from the recurring templates, runs once on
+// startup and then on every interval
+func runScheduler(app core.App) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx := context.Background()
+
+		count, err := apis.RunRecurringTemplates(ctx, app, time.Now())
+		if err != nil {
+			log.Error("Failed to run recurring templates", "err", err)
+		} else if count > 0 {
+			log.Info("Created tasks from recurring templates", "count", count)
+		}
+
+		<-ticker.C
+	}
+}
+
+// backfillReferences records the mentions inside the existing tasks and
+// comments, only runs once per work directory
+func backfillReferences(app core.App) error {
+	file := app.WorkDir().ReferencesFile()
+
+	_, err := os.Stat(file)
+	if err == nil {
+		return nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	count, err := apis.BackfillTaskReferences(context.Background(), app)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Backfilled task references", "count", count)
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
 var serveCmd = &cobra.Command{
 	Use: "serve",
 	Run: func(cmd *cobra.Command, args []string) {
@@ -18,6 +105,14 @@ var serveCmd = &cobra.Command{
 			log.Fatal("Failed to bootstrap app", "err", err)
 		}
 
+		err = backfillReferences(app)
+		if err != nil {
+			log.Fatal("Failed to backfill task references", "err", err)
+		}
+
+		go runMaintenance(app)
+		go runScheduler(app)
+
 		e, err := apis.Server(app)
 		if err != nil {
 			log.Fatal("Failed to create server", "err", err)
@@ -16,6 +16,16 @@ type Config struct {
 	Username        string `mapstructure:"username"`
 	InitialPassword string `mapstructure:"initial_password"`
 	JwtSecret       string `mapstructure:"jwt_secret"`
+
+	// NOTE(patrik): Items in the trash is purged after this many days, 0
+	// keeps the items until they are purged manually
+	TrashRetentionDays int `mapstructure:"trash_retention_days"`
+
+	// NOTE(patrik): Max size in bytes of a single uploaded file
+	MaxUploadSize int64 `mapstructure:"max_upload_size"`
+	// NOTE(patrik): Content types that can be uploaded, "image/*" matches
+	// every image type and an empty list allows everything
+	AllowedUploadTypes []string `mapstructure:"allowed_upload_types"`
 }
 
 func (c *Config) WorkDir() types.WorkDir {
@@ -25,6 +35,14 @@ func (c *Config) WorkDir() types.WorkDir {
 func setDefaults() {
 	viper.SetDefault("run_migrations", "true")
 	viper.SetDefault("listen_addr", ":3000")
+	viper.SetDefault("trash_retention_days", 30)
+	viper.SetDefault("max_upload_size", 10*1024*1024)
+	viper.SetDefault("allowed_upload_types", []string{
+		"image/*",
+		"text/plain",
+		"application/pdf",
+		"application/zip",
+	})
 	viper.BindEnv("data_dir")
 	viper.BindEnv("username")
 	viper.BindEnv("initial_password")
@@ -48,6 +66,8 @@ func validateConfig(config *Config) {
 	validate(config.Username == "", "username needs to be set")
 	validate(config.InitialPassword == "", "initial_password needs to be set")
 	validate(config.JwtSecret == "", "jwt_secret needs to be set")
+	validate(config.TrashRetentionDays < 0, "trash_retention_days can't be negative")
+	validate(config.MaxUploadSize <= 0, "max_upload_size needs to be greater than 0")
 
 	if hasError {
 		log.Fatal("Config not valid")
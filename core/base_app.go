@@ -37,6 +37,7 @@ func (app *BaseApp) Bootstrap() error {
 
 	dirs := []string{
 		workDir.Trash(),
+		workDir.Attachments(),
 	}
 
 	for _, dir := range dirs {
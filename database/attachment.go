@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type Attachment struct {
+	RowId int `db:"rowid"`
+
+	Id string `db:"id"`
+
+	TaskId string `db:"task_id"`
+	UserId string `db:"user_id"`
+
+	Filename      string `db:"filename"`
+	ContentType   string `db:"content_type"`
+	Size          int64  `db:"size"`
+	HasThumbnails bool   `db:"has_thumbnails"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	ProjectId string `db:"project_id"`
+}
+
+// AttachmentQuery returns the attachments that is not in the trash and
+// belongs to tasks that is not in the trash
+func AttachmentQuery() *goqu.SelectDataset {
+	query := dialect.From("attachments").
+		Select(
+			"attachments.rowid",
+
+			"attachments.id",
+
+			"attachments.task_id",
+			"attachments.user_id",
+
+			"attachments.filename",
+			"attachments.content_type",
+			"attachments.size",
+			"attachments.has_thumbnails",
+
+			"attachments.created",
+			"attachments.updated",
+
+			goqu.I("tasks.project_id").As("project_id"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("tasks"),
+			goqu.On(goqu.I("attachments.task_id").Eq(goqu.I("tasks.id"))),
+		).
+		Where(
+			goqu.I("attachments.trash_id").IsNull(),
+			goqu.I("tasks.trash_id").IsNull(),
+		).
+		Order(goqu.I("attachments.created").Asc())
+
+	return query
+}
+
+func (db *Database) GetAttachmentById(ctx context.Context, id string) (Attachment, error) {
+	query := AttachmentQuery().
+		Where(goqu.I("attachments.id").Eq(id))
+
+	var item Attachment
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Attachment{}, ErrItemNotFound
+		}
+
+		return Attachment{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskAttachments(ctx context.Context, taskId string) ([]Attachment, error) {
+	query := AttachmentQuery().
+		Where(goqu.I("attachments.task_id").Eq(taskId))
+
+	var items []Attachment
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetAttachmentIdsByTrashItem returns the ids of the attachments that is
+// removed when the trash item is purged, attachments that is in the trash by
+// themselves is not included
+func (db *Database) GetAttachmentIdsByTrashItem(ctx context.Context, item TrashItem) ([]string, error) {
+	var where goqu.Expression
+
+	switch item.Type {
+	case types.TrashTypeProject:
+		where = goqu.I("tasks.project_id").Eq(item.ItemId)
+	case types.TrashTypeBoard:
+		where = goqu.I("tasks.board_id").Eq(item.ItemId)
+	case types.TrashTypeTask:
+		// NOTE(patrik): Includes the subtasks trashed together with the task
+		where = goqu.Or(
+			goqu.I("tasks.id").Eq(item.ItemId),
+			goqu.I("tasks.trash_id").Eq(item.Id),
+		)
+	default:
+		return nil, nil
+	}
+
+	query := dialect.From("attachments").
+		Prepared(true).
+		Select("attachments.id").
+		Join(
+			goqu.I("tasks"),
+			goqu.On(goqu.I("attachments.task_id").Eq(goqu.I("tasks.id"))),
+		).
+		Where(
+			where,
+			goqu.I("attachments.trash_id").IsNull(),
+		)
+
+	var items []string
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateAttachmentParams struct {
+	Id string
+
+	TaskId string
+	UserId string
+
+	Filename      string
+	ContentType   string
+	Size          int64
+	HasThumbnails bool
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateAttachment(ctx context.Context, params CreateAttachmentParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateAttachmentId()
+	}
+
+	query := dialect.Insert("attachments").
+		Rows(goqu.Record{
+			"id": id,
+
+			"task_id": params.TaskId,
+			"user_id": params.UserId,
+
+			"filename":       params.Filename,
+			"content_type":   params.ContentType,
+			"size":           params.Size,
+			"has_thumbnails": params.HasThumbnails,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("attachments.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
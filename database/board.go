@@ -41,7 +41,24 @@ func BoardQuery() *goqu.SelectDataset {
 			"boards.created",
 			"boards.updated",
 		).
-		Prepared(true)
+		Prepared(true).
+		Where(goqu.I("boards.trash_id").IsNull())
+
+	return query
+}
+
+// DoneBoardQuery selects the id of the done board for the project, the
+// done board is the last visible board of the project
+func DoneBoardQuery(projectId any) *goqu.SelectDataset {
+	query := dialect.From(goqu.T("boards").As("done_boards")).
+		Select("done_boards.id").
+		Where(
+			goqu.I("done_boards.project_id").Eq(projectId),
+			goqu.I("done_boards.order_number").IsNotNull(),
+			goqu.I("done_boards.trash_id").IsNull(),
+		).
+		Order(goqu.I("done_boards.order_number").Desc()).
+		Limit(1)
 
 	return query
 }
@@ -75,6 +92,24 @@ func (db *Database) GetBoardById(ctx context.Context, id string) (Board, error)
 	return item, nil
 }
 
+// GetDoneBoard returns the done board of the project, see DoneBoardQuery
+func (db *Database) GetDoneBoard(ctx context.Context, projectId string) (Board, error) {
+	query := BoardQuery().
+		Where(goqu.I("boards.id").Eq(DoneBoardQuery(projectId)))
+
+	var item Board
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Board{}, ErrItemNotFound
+		}
+
+		return Board{}, err
+	}
+
+	return item, nil
+}
+
 func (db *Database) GetBoardsByProject(ctx context.Context, projectId string, hidden bool) ([]Board, error) {
 	query := BoardQuery()
 
@@ -26,9 +26,10 @@ type ToSQL interface {
 }
 
 type Connection interface {
-	Query(query string, args ...any) (*sql.Rows, error)
+	sqlx.Queryer
+	sqlx.Execer
+
 	QueryRow(query string, args ...any) *sql.Row
-	Exec(query string, args ...any) (sql.Result, error)
 }
 
 type Database struct {
@@ -38,15 +39,20 @@ type Database struct {
 }
 
 func New(conn *sql.DB) *Database {
+	newConn := sqlx.NewDb(conn, "sqlite3")
+
 	return &Database{
-		NewRawConn: sqlx.NewDb(conn, "sqlite3"),
+		NewRawConn: newConn,
 		RawConn:    conn,
-		Conn:       conn,
+		Conn:       newConn,
 	}
 }
 
 func Open(workDir types.WorkDir) (*Database, error) {
-	dbUrl := fmt.Sprintf("file:%s?_foreign_keys=true", workDir.DatabaseFile())
+	// NOTE(patrik): The busy timeout and immediate transactions makes
+	// concurrent writers wait for each other instead of failing with
+	// "database is locked"
+	dbUrl := fmt.Sprintf("file:%s?_foreign_keys=true&_busy_timeout=5000&_txlock=immediate", workDir.DatabaseFile())
 
 	conn, err := sql.Open("sqlite3", dbUrl)
 	if err != nil {
@@ -77,6 +83,13 @@ func (db *Database) Begin() (*Database, *sqlx.Tx, error) {
 	}, tx, nil
 }
 
+// InTransaction reports if the database is bound to a transaction created
+// by Begin
+func (db *Database) InTransaction() bool {
+	_, ok := db.Conn.(*sqlx.Tx)
+	return ok
+}
+
 func (db *Database) Query(ctx context.Context, s ToSQL) (*sql.Rows, error) {
 	sql, params, err := s.ToSQL()
 	if err != nil {
@@ -111,7 +124,9 @@ func (db *Database) Select(dest any, s ToSQL) error {
 		return err
 	}
 
-	return db.NewRawConn.Select(dest, sql, params...)
+	// NOTE(patrik): Use the current connection so that queries run
+	// inside a transaction see the changes made by that transaction
+	return sqlx.Select(db.Conn, dest, sql, params...)
 }
 
 func (db *Database) Get(dest any, s ToSQL) error {
@@ -120,7 +135,7 @@ func (db *Database) Get(dest any, s ToSQL) error {
 		return err
 	}
 
-	return db.NewRawConn.Get(dest, sql, params...)
+	return sqlx.Get(db.Conn, dest, sql, params...)
 }
 
 func init() {
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE task_comments (
+    id TEXT PRIMARY KEY,
+
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    parent_id TEXT REFERENCES task_comments(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    content TEXT NOT NULL CHECK(content<>''),
+
+    edited INTEGER,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE task_comments;
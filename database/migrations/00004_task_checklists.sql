@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE task_checklists (
+    id TEXT PRIMARY KEY,
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL CHECK(name<>''),
+
+    order_number INT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE TABLE task_checklist_items (
+    id TEXT PRIMARY KEY,
+    checklist_id TEXT NOT NULL REFERENCES task_checklists(id) ON DELETE CASCADE,
+
+    title TEXT NOT NULL CHECK(title<>''),
+    checked BOOLEAN NOT NULL DEFAULT FALSE,
+
+    order_number INT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+-- +goose Down
+DROP TABLE task_checklist_items;
+DROP TABLE task_checklists;
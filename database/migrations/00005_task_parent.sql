@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN parent_id TEXT REFERENCES tasks(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE tasks DROP COLUMN parent_id;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE task_relations (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+    from_task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    to_task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL,
+
+    CHECK(from_task_id<>to_task_id),
+    UNIQUE(from_task_id, to_task_id, type)
+);
+
+-- +goose Down
+DROP TABLE task_relations;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN due INTEGER;
+ALTER TABLE tasks ADD COLUMN due_has_time BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users_settings ADD COLUMN timezone TEXT;
+
+-- +goose Down
+ALTER TABLE users_settings DROP COLUMN timezone;
+
+ALTER TABLE tasks DROP COLUMN due_has_time;
+ALTER TABLE tasks DROP COLUMN due;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE project_fields (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    options TEXT,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE TABLE task_field_values (
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    field_id TEXT NOT NULL REFERENCES project_fields(id) ON DELETE CASCADE,
+
+    value TEXT NOT NULL,
+
+    PRIMARY KEY(task_id, field_id)
+);
+
+-- +goose Down
+DROP TABLE task_field_values;
+DROP TABLE project_fields;
@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE projects ADD COLUMN key TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN task_counter INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE tasks ADD COLUMN number INTEGER NOT NULL DEFAULT 0;
+
+UPDATE projects SET key = 'P' || projects.rowid;
+
+UPDATE tasks SET number = (
+    SELECT COUNT(*) FROM tasks AS t
+    WHERE t.project_id = tasks.project_id AND
+        (t.created < tasks.created OR (t.created = tasks.created AND t.rowid <= tasks.rowid))
+);
+
+UPDATE projects SET task_counter = (
+    SELECT COUNT(*) FROM tasks WHERE tasks.project_id = projects.id
+);
+
+CREATE UNIQUE INDEX projects_owner_key_idx ON projects(owner_id, key);
+CREATE UNIQUE INDEX tasks_project_number_idx ON tasks(project_id, number);
+
+-- +goose Down
+DROP INDEX tasks_project_number_idx;
+DROP INDEX projects_owner_key_idx;
+
+ALTER TABLE tasks DROP COLUMN number;
+
+ALTER TABLE projects DROP COLUMN task_counter;
+ALTER TABLE projects DROP COLUMN key;
@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN rank TEXT NOT NULL DEFAULT '';
+
+-- NOTE(patrik): Existing tasks keeps the title order, the ranks are valid
+-- fractional keys ("a0" integer part and a fixed width fraction not ending
+-- with a zero)
+UPDATE tasks SET rank = 'a0' || printf('%08d', (
+    SELECT COUNT(*) FROM tasks AS t
+    WHERE t.board_id = tasks.board_id AND
+        (t.title < tasks.title OR (t.title = tasks.title AND t.rowid <= tasks.rowid))
+)) || 'V';
+
+CREATE INDEX tasks_board_rank_idx ON tasks(board_id, rank);
+
+-- +goose Down
+DROP INDEX tasks_board_rank_idx;
+
+ALTER TABLE tasks DROP COLUMN rank;
@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE tasks ADD COLUMN archived INTEGER;
+ALTER TABLE tasks ADD COLUMN moved INTEGER NOT NULL DEFAULT 0;
+
+UPDATE tasks SET moved = updated;
+
+ALTER TABLE projects ADD COLUMN auto_archive_days INTEGER;
+
+-- +goose Down
+ALTER TABLE projects DROP COLUMN auto_archive_days;
+
+ALTER TABLE tasks DROP COLUMN moved;
+ALTER TABLE tasks DROP COLUMN archived;
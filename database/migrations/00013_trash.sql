@@ -0,0 +1,34 @@
+-- +goose Up
+CREATE TABLE trash (
+    id TEXT PRIMARY KEY,
+    owner_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+    type TEXT NOT NULL,
+    item_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+-- NOTE(patrik): The trash id is set on the trashed item and on every item
+-- that was trashed together with it, restoring clears it again
+ALTER TABLE projects ADD COLUMN trash_id TEXT;
+ALTER TABLE boards ADD COLUMN trash_id TEXT;
+ALTER TABLE tasks ADD COLUMN trash_id TEXT;
+
+CREATE INDEX projects_trash_idx ON projects(trash_id);
+CREATE INDEX boards_trash_idx ON boards(trash_id);
+CREATE INDEX tasks_trash_idx ON tasks(trash_id);
+
+-- +goose Down
+DROP INDEX tasks_trash_idx;
+DROP INDEX boards_trash_idx;
+DROP INDEX projects_trash_idx;
+
+ALTER TABLE tasks DROP COLUMN trash_id;
+ALTER TABLE boards DROP COLUMN trash_id;
+ALTER TABLE projects DROP COLUMN trash_id;
+
+DROP TABLE trash;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE task_history (
+    id TEXT PRIMARY KEY,
+
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- NOTE(patrik): JSON array with the before and after value of every
+    -- field that was changed
+    changes TEXT NOT NULL,
+
+    created INTEGER NOT NULL
+);
+
+CREATE INDEX task_history_task_idx ON task_history(task_id);
+
+-- +goose Down
+DROP INDEX task_history_task_idx;
+
+DROP TABLE task_history;
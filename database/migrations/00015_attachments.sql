@@ -0,0 +1,26 @@
+-- +goose Up
+CREATE TABLE attachments (
+    id TEXT PRIMARY KEY,
+
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size INTEGER NOT NULL,
+    has_thumbnails BOOLEAN NOT NULL,
+
+    trash_id TEXT,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE INDEX attachments_task_idx ON attachments(task_id);
+CREATE INDEX attachments_trash_idx ON attachments(trash_id);
+
+-- +goose Down
+DROP INDEX attachments_trash_idx;
+DROP INDEX attachments_task_idx;
+
+DROP TABLE attachments;
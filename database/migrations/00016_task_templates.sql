@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE task_templates (
+    id TEXT PRIMARY KEY,
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    board_id TEXT REFERENCES boards(id) ON DELETE SET NULL,
+
+    name TEXT NOT NULL CHECK(name<>''),
+    title TEXT NOT NULL CHECK(title<>''),
+    description TEXT,
+
+    -- NOTE(patrik): JSON arrays of strings
+    tags TEXT NOT NULL,
+    checklist TEXT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE INDEX task_templates_project_idx ON task_templates(project_id);
+
+-- +goose Down
+DROP INDEX task_templates_project_idx;
+
+DROP TABLE task_templates;
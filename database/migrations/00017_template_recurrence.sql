@@ -0,0 +1,16 @@
+-- +goose Up
+ALTER TABLE task_templates ADD COLUMN recurrence TEXT;
+-- NOTE(patrik): The time the recurrence was set, the interval of the rule is
+-- counted from here
+ALTER TABLE task_templates ADD COLUMN recurrence_start INTEGER;
+-- NOTE(patrik): The time of the next occurrence that hasn't been created
+ALTER TABLE task_templates ADD COLUMN recurrence_next INTEGER;
+
+CREATE INDEX task_templates_recurrence_next_idx ON task_templates(recurrence_next);
+
+-- +goose Down
+DROP INDEX task_templates_recurrence_next_idx;
+
+ALTER TABLE task_templates DROP COLUMN recurrence_next;
+ALTER TABLE task_templates DROP COLUMN recurrence_start;
+ALTER TABLE task_templates DROP COLUMN recurrence;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE time_entries (
+    id TEXT PRIMARY KEY,
+
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    description TEXT,
+
+    started INTEGER NOT NULL,
+    -- NOTE(patrik): NULL while the timer is running
+    ended INTEGER,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE INDEX time_entries_task_idx ON time_entries(task_id);
+CREATE INDEX time_entries_started_idx ON time_entries(started);
+
+-- NOTE(patrik): A user can only have one running timer
+CREATE UNIQUE INDEX time_entries_running_idx ON time_entries(user_id) WHERE ended IS NULL;
+
+-- +goose Down
+DROP INDEX time_entries_running_idx;
+DROP INDEX time_entries_started_idx;
+DROP INDEX time_entries_task_idx;
+
+DROP TABLE time_entries;
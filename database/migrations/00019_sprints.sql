@@ -0,0 +1,36 @@
+-- +goose Up
+CREATE TABLE sprints (
+    id TEXT PRIMARY KEY,
+
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+
+    -- NOTE(patrik): Dates is stored as midnight UTC, same as due dates
+    -- without time, both dates is inclusive
+    start_date INTEGER NOT NULL,
+    end_date INTEGER NOT NULL,
+
+    closed INTEGER,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE INDEX sprints_project_idx ON sprints(project_id);
+
+-- NOTE(patrik): Story points
+ALTER TABLE tasks ADD COLUMN estimate INTEGER;
+ALTER TABLE tasks ADD COLUMN sprint_id TEXT REFERENCES sprints(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_sprint_idx ON tasks(sprint_id);
+
+-- +goose Down
+DROP INDEX tasks_sprint_idx;
+
+ALTER TABLE tasks DROP COLUMN sprint_id;
+ALTER TABLE tasks DROP COLUMN estimate;
+
+DROP INDEX sprints_project_idx;
+
+DROP TABLE sprints;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE milestones (
+    id TEXT PRIMARY KEY,
+
+    project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+    description TEXT,
+
+    -- NOTE(patrik): Stored as midnight UTC, same as due dates without time
+    target_date INTEGER,
+    status TEXT NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL
+);
+
+CREATE INDEX milestones_project_idx ON milestones(project_id);
+
+ALTER TABLE tasks ADD COLUMN milestone_id TEXT REFERENCES milestones(id) ON DELETE SET NULL;
+
+CREATE INDEX tasks_milestone_idx ON tasks(milestone_id);
+
+-- +goose Down
+DROP INDEX tasks_milestone_idx;
+
+ALTER TABLE tasks DROP COLUMN milestone_id;
+
+DROP INDEX milestones_project_idx;
+
+DROP TABLE milestones;
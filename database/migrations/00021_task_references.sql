@@ -0,0 +1,24 @@
+-- +goose Up
+-- NOTE(patrik): The mentions inside the existing tasks and comments is
+-- backfilled by the server on the first start after the migration
+CREATE TABLE task_references (
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    -- NOTE(patrik): NULL when the mention is inside the title or the
+    -- description of the task
+    comment_id TEXT REFERENCES task_comments(id) ON DELETE CASCADE,
+
+    target_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+
+    created INTEGER NOT NULL,
+
+    CHECK(task_id<>target_id)
+);
+
+CREATE UNIQUE INDEX task_references_source_idx ON task_references(task_id, IFNULL(comment_id, ''), target_id);
+CREATE INDEX task_references_target_idx ON task_references(target_id);
+
+-- +goose Down
+DROP INDEX task_references_target_idx;
+DROP INDEX task_references_source_idx;
+
+DROP TABLE task_references;
@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE task_watchers (
+    task_id TEXT NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    -- NOTE(patrik): FALSE when the user has unwatched the task, keeps the
+    -- user from being subscribed again by commenting or by watching the
+    -- board the task is on
+    watching BOOLEAN NOT NULL,
+
+    created INTEGER NOT NULL,
+    updated INTEGER NOT NULL,
+
+    PRIMARY KEY(task_id, user_id)
+);
+
+CREATE INDEX task_watchers_user_idx ON task_watchers(user_id);
+
+CREATE TABLE board_watchers (
+    board_id TEXT NOT NULL REFERENCES boards(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+
+    created INTEGER NOT NULL,
+
+    PRIMARY KEY(board_id, user_id)
+);
+
+CREATE INDEX board_watchers_user_idx ON board_watchers(user_id);
+
+-- NOTE(patrik): The creator of the existing tasks isn't known so the
+-- project owner is subscribed, the commenters is subscribed as well
+INSERT INTO task_watchers (task_id, user_id, watching, created, updated)
+SELECT tasks.id, projects.owner_id, TRUE, tasks.created, tasks.created
+FROM tasks JOIN projects ON tasks.project_id = projects.id;
+
+INSERT OR IGNORE INTO task_watchers (task_id, user_id, watching, created, updated)
+SELECT task_id, user_id, TRUE, MIN(created), MIN(created)
+FROM task_comments GROUP BY task_id, user_id;
+
+-- +goose Down
+DROP INDEX board_watchers_user_idx;
+DROP TABLE board_watchers;
+
+DROP INDEX task_watchers_user_idx;
+DROP TABLE task_watchers;
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type Milestone struct {
+	RowId int `db:"rowid"`
+
+	Id        string `db:"id"`
+	ProjectId string `db:"project_id"`
+
+	Name        string         `db:"name"`
+	Description sql.NullString `db:"description"`
+
+	TargetDate sql.NullInt64         `db:"target_date"`
+	Status     types.MilestoneStatus `db:"status"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	TaskCount int64 `db:"task_count"`
+	TaskDone  int64 `db:"task_done"`
+
+	Points     int64 `db:"points"`
+	PointsDone int64 `db:"points_done"`
+}
+
+// MilestoneSummaryQuery counts the tasks and the story points of every
+// milestone and how much of it is on the done board
+func MilestoneSummaryQuery() *goqu.SelectDataset {
+	done := goqu.I("tasks.board_id").Eq(DoneBoardQuery(goqu.I("tasks.project_id")))
+	estimate := goqu.COALESCE(goqu.I("tasks.estimate"), 0)
+
+	query := dialect.From("tasks").
+		Select(
+			goqu.I("tasks.milestone_id").As("milestone_id"),
+
+			goqu.COUNT(goqu.I("tasks.id")).As("task_count"),
+			goqu.SUM(done).As("task_done"),
+
+			goqu.SUM(estimate).As("points"),
+			goqu.SUM(goqu.L("? * ?", done, estimate)).As("points_done"),
+		).
+		Where(
+			goqu.I("tasks.milestone_id").IsNotNull(),
+			goqu.I("tasks.trash_id").IsNull(),
+		).
+		GroupBy(goqu.I("tasks.milestone_id"))
+
+	return query
+}
+
+func MilestoneQuery() *goqu.SelectDataset {
+	query := dialect.From("milestones").
+		Select(
+			"milestones.rowid",
+
+			"milestones.id",
+			"milestones.project_id",
+
+			"milestones.name",
+			"milestones.description",
+
+			"milestones.target_date",
+			"milestones.status",
+
+			"milestones.created",
+			"milestones.updated",
+
+			goqu.COALESCE(goqu.I("summary.task_count"), 0).As("task_count"),
+			goqu.COALESCE(goqu.I("summary.task_done"), 0).As("task_done"),
+
+			goqu.COALESCE(goqu.I("summary.points"), 0).As("points"),
+			goqu.COALESCE(goqu.I("summary.points_done"), 0).As("points_done"),
+		).
+		Prepared(true).
+		LeftJoin(
+			MilestoneSummaryQuery().As("summary"),
+			goqu.On(goqu.I("milestones.id").Eq(goqu.I("summary.milestone_id"))),
+		).
+		Order(
+			goqu.I("milestones.target_date").Asc().NullsLast(),
+			goqu.I("milestones.created").Asc(),
+		)
+
+	return query
+}
+
+func (db *Database) GetMilestoneById(ctx context.Context, id string) (Milestone, error) {
+	query := MilestoneQuery().
+		Where(goqu.I("milestones.id").Eq(id))
+
+	var item Milestone
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Milestone{}, ErrItemNotFound
+		}
+
+		return Milestone{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetMilestonesByProject(ctx context.Context, projectId string) ([]Milestone, error) {
+	query := MilestoneQuery().
+		Where(goqu.I("milestones.project_id").Eq(projectId))
+
+	var items []Milestone
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateMilestoneParams struct {
+	Id        string
+	ProjectId string
+
+	Name        string
+	Description sql.NullString
+
+	TargetDate sql.NullInt64
+	Status     types.MilestoneStatus
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateMilestone(ctx context.Context, params CreateMilestoneParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateMilestoneId()
+	}
+
+	query := dialect.Insert("milestones").
+		Rows(goqu.Record{
+			"id":         id,
+			"project_id": params.ProjectId,
+
+			"name":        params.Name,
+			"description": params.Description,
+
+			"target_date": params.TargetDate,
+			"status":      params.Status,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("milestones.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+type MilestoneChanges struct {
+	Name        types.Change[string]
+	Description types.Change[sql.NullString]
+
+	TargetDate types.Change[sql.NullInt64]
+	Status     types.Change[types.MilestoneStatus]
+}
+
+func (db *Database) UpdateMilestone(ctx context.Context, id string, changes MilestoneChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "name", changes.Name)
+	addToRecord(record, "description", changes.Description)
+
+	addToRecord(record, "target_date", changes.TargetDate)
+	addToRecord(record, "status", changes.Status)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("milestones").
+		Set(record).
+		Where(goqu.I("milestones.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteMilestone(ctx context.Context, id string) error {
+	query := dialect.Delete("milestones").
+		Prepared(true).
+		Where(goqu.I("milestones.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/mattn/go-sqlite3"
 	"github.com/nanoteck137/beldum/tools/utils"
 	"github.com/nanoteck137/beldum/types"
 )
@@ -16,9 +17,14 @@ type Project struct {
 
 	Id   string `db:"id"`
 	Name string `db:"name"`
+	Key  string `db:"key"`
 
 	OwnerId string `db:"owner_id"`
 
+	TaskCounter int64 `db:"task_counter"`
+
+	AutoArchiveDays sql.NullInt64 `db:"auto_archive_days"`
+
 	Created int64 `db:"created"`
 	Updated int64 `db:"updated"`
 }
@@ -30,13 +36,19 @@ func ProjectQuery() *goqu.SelectDataset {
 
 			"projects.id",
 			"projects.name",
+			"projects.key",
 
 			"projects.owner_id",
 
+			"projects.task_counter",
+
+			"projects.auto_archive_days",
+
 			"projects.created",
 			"projects.updated",
 		).
-		Prepared(true)
+		Prepared(true).
+		Where(goqu.I("projects.trash_id").IsNull())
 
 	return query
 }
@@ -86,6 +98,7 @@ func (db *Database) GetProjectsByUser(ctx context.Context, userId string) ([]Pro
 type CreateProjectParams struct {
 	Id   string
 	Name string
+	Key  string
 
 	OwnerId string
 
@@ -112,6 +125,7 @@ func (db *Database) CreateProject(ctx context.Context, params CreateProjectParam
 		Rows(goqu.Record{
 			"id":   id,
 			"name": params.Name,
+			"key":  params.Key,
 
 			"owner_id": params.OwnerId,
 
@@ -121,9 +135,14 @@ func (db *Database) CreateProject(ctx context.Context, params CreateProjectParam
 		Returning(
 			"projects.id",
 			"projects.name",
+			"projects.key",
 
 			"projects.owner_id",
 
+			"projects.task_counter",
+
+			"projects.auto_archive_days",
+
 			"projects.created",
 			"projects.updated",
 		).
@@ -132,6 +151,13 @@ func (db *Database) CreateProject(ctx context.Context, params CreateProjectParam
 	var item Project
 	err := db.Get(&item, query)
 	if err != nil {
+		var e sqlite3.Error
+		if errors.As(err, &e) {
+			if e.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return Project{}, ErrItemAlreadyExists
+			}
+		}
+
 		return Project{}, err
 	}
 
@@ -154,6 +180,8 @@ func (db *Database) DeleteProject(ctx context.Context, id string) error {
 type ProjectChanges struct {
 	Name types.Change[string]
 
+	AutoArchiveDays types.Change[sql.NullInt64]
+
 	OwnerId types.Change[string]
 
 	Created types.Change[int64]
@@ -164,6 +192,8 @@ func (db *Database) UpdateProject(ctx context.Context, id string, changes Projec
 
 	addToRecord(record, "name", changes.Name)
 
+	addToRecord(record, "auto_archive_days", changes.AutoArchiveDays)
+
 	addToRecord(record, "owner_id", changes.OwnerId)
 
 	addToRecord(record, "created", changes.Created)
@@ -186,3 +216,28 @@ func (db *Database) UpdateProject(ctx context.Context, id string, changes Projec
 
 	return nil
 }
+
+// NextTaskNumber increments the task counter of the project and returns the
+// new value, the increment is a single statement so concurrent creates
+// never gets the same number
+func (db *Database) NextTaskNumber(ctx context.Context, projectId string) (int64, error) {
+	query := dialect.Update("projects").
+		Prepared(true).
+		Set(goqu.Record{
+			"task_counter": goqu.L("? + 1", goqu.I("task_counter")),
+		}).
+		Where(goqu.I("projects.id").Eq(projectId)).
+		Returning("projects.task_counter")
+
+	var item int64
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrItemNotFound
+		}
+
+		return 0, err
+	}
+
+	return item, nil
+}
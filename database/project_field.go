@@ -0,0 +1,248 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type ProjectField struct {
+	RowId int `db:"rowid"`
+
+	Id        string `db:"id"`
+	ProjectId string `db:"project_id"`
+
+	Name    string          `db:"name"`
+	Type    types.FieldType `db:"type"`
+	Options sql.NullString  `db:"options"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+}
+
+func ProjectFieldQuery() *goqu.SelectDataset {
+	query := dialect.From("project_fields").
+		Select(
+			"project_fields.rowid",
+
+			"project_fields.id",
+			"project_fields.project_id",
+
+			"project_fields.name",
+			"project_fields.type",
+			"project_fields.options",
+
+			"project_fields.created",
+			"project_fields.updated",
+		).
+		Prepared(true).
+		Order(goqu.I("project_fields.created").Asc())
+
+	return query
+}
+
+// TaskFieldValuesQuery collects the custom field values of every task into
+// a JSON array with the name and type of the field included
+func TaskFieldValuesQuery() *goqu.SelectDataset {
+	query := dialect.From("task_field_values").
+		Select(
+			goqu.I("task_field_values.task_id").As("task_id"),
+			goqu.L(
+				"json_group_array(? ORDER BY ?)",
+				goqu.Func("json_object",
+					"id", goqu.I("project_fields.id"),
+					"name", goqu.I("project_fields.name"),
+					"type", goqu.I("project_fields.type"),
+					"value", goqu.I("task_field_values.value"),
+				),
+				goqu.I("project_fields.created"),
+			).As("fields"),
+		).
+		Join(
+			goqu.I("project_fields"),
+			goqu.On(goqu.I("task_field_values.field_id").Eq(goqu.I("project_fields.id"))),
+		).
+		GroupBy(goqu.I("task_field_values.task_id"))
+
+	return query
+}
+
+func (db *Database) GetProjectFieldById(ctx context.Context, id string) (ProjectField, error) {
+	query := ProjectFieldQuery().
+		Where(goqu.I("project_fields.id").Eq(id))
+
+	var item ProjectField
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ProjectField{}, ErrItemNotFound
+		}
+
+		return ProjectField{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetProjectFields(ctx context.Context, projectId string) ([]ProjectField, error) {
+	query := ProjectFieldQuery().
+		Where(goqu.I("project_fields.project_id").Eq(projectId))
+
+	var items []ProjectField
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateProjectFieldParams struct {
+	Id        string
+	ProjectId string
+
+	Name    string
+	Type    types.FieldType
+	Options sql.NullString
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateProjectField(ctx context.Context, params CreateProjectFieldParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateFieldId()
+	}
+
+	query := dialect.Insert("project_fields").
+		Rows(goqu.Record{
+			"id":         id,
+			"project_id": params.ProjectId,
+
+			"name":    params.Name,
+			"type":    params.Type,
+			"options": params.Options,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("project_fields.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+type ProjectFieldChanges struct {
+	Name    types.Change[string]
+	Options types.Change[sql.NullString]
+}
+
+func (db *Database) UpdateProjectField(ctx context.Context, id string, changes ProjectFieldChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "name", changes.Name)
+	addToRecord(record, "options", changes.Options)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("project_fields").
+		Set(record).
+		Where(goqu.I("project_fields.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteProjectField(ctx context.Context, id string) error {
+	query := dialect.Delete("project_fields").
+		Prepared(true).
+		Where(goqu.I("project_fields.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetTaskFieldValue inserts or replaces the value of a custom field on a
+// task, the value is expected to already be validated and normalized
+func (db *Database) SetTaskFieldValue(ctx context.Context, taskId, fieldId, value string) error {
+	query := dialect.Insert("task_field_values").
+		Prepared(true).
+		Rows(goqu.Record{
+			"task_id":  taskId,
+			"field_id": fieldId,
+			"value":    value,
+		}).
+		OnConflict(goqu.DoUpdate("task_id, field_id", goqu.Record{
+			"value": goqu.I("excluded.value"),
+		}))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) RemoveTaskFieldValue(ctx context.Context, taskId, fieldId string) error {
+	query := dialect.Delete("task_field_values").
+		Prepared(true).
+		Where(
+			goqu.I("task_field_values.task_id").Eq(taskId),
+			goqu.I("task_field_values.field_id").Eq(fieldId),
+		)
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) RemoveAllTaskFieldValues(ctx context.Context, taskId string) error {
+	query := dialect.Delete("task_field_values").
+		Prepared(true).
+		Where(goqu.I("task_field_values.task_id").Eq(taskId))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
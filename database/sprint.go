@@ -0,0 +1,288 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type Sprint struct {
+	RowId int `db:"rowid"`
+
+	Id        string `db:"id"`
+	ProjectId string `db:"project_id"`
+
+	Name string `db:"name"`
+
+	StartDate int64 `db:"start_date"`
+	EndDate   int64 `db:"end_date"`
+
+	Closed sql.NullInt64 `db:"closed"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	TaskCount int64 `db:"task_count"`
+	TaskDone  int64 `db:"task_done"`
+
+	Points     int64 `db:"points"`
+	PointsDone int64 `db:"points_done"`
+}
+
+// SprintSummaryQuery counts the tasks and the story points of every sprint
+// and how much of it is on the done board
+func SprintSummaryQuery() *goqu.SelectDataset {
+	done := goqu.I("tasks.board_id").Eq(DoneBoardQuery(goqu.I("tasks.project_id")))
+	estimate := goqu.COALESCE(goqu.I("tasks.estimate"), 0)
+
+	query := dialect.From("tasks").
+		Select(
+			goqu.I("tasks.sprint_id").As("sprint_id"),
+
+			goqu.COUNT(goqu.I("tasks.id")).As("task_count"),
+			goqu.SUM(done).As("task_done"),
+
+			goqu.SUM(estimate).As("points"),
+			goqu.SUM(goqu.L("? * ?", done, estimate)).As("points_done"),
+		).
+		Where(
+			goqu.I("tasks.sprint_id").IsNotNull(),
+			goqu.I("tasks.trash_id").IsNull(),
+		).
+		GroupBy(goqu.I("tasks.sprint_id"))
+
+	return query
+}
+
+func SprintQuery() *goqu.SelectDataset {
+	query := dialect.From("sprints").
+		Select(
+			"sprints.rowid",
+
+			"sprints.id",
+			"sprints.project_id",
+
+			"sprints.name",
+
+			"sprints.start_date",
+			"sprints.end_date",
+
+			"sprints.closed",
+
+			"sprints.created",
+			"sprints.updated",
+
+			goqu.COALESCE(goqu.I("summary.task_count"), 0).As("task_count"),
+			goqu.COALESCE(goqu.I("summary.task_done"), 0).As("task_done"),
+
+			goqu.COALESCE(goqu.I("summary.points"), 0).As("points"),
+			goqu.COALESCE(goqu.I("summary.points_done"), 0).As("points_done"),
+		).
+		Prepared(true).
+		LeftJoin(
+			SprintSummaryQuery().As("summary"),
+			goqu.On(goqu.I("sprints.id").Eq(goqu.I("summary.sprint_id"))),
+		).
+		Order(
+			goqu.I("sprints.start_date").Asc(),
+			goqu.I("sprints.created").Asc(),
+		)
+
+	return query
+}
+
+func (db *Database) GetSprintById(ctx context.Context, id string) (Sprint, error) {
+	query := SprintQuery().
+		Where(goqu.I("sprints.id").Eq(id))
+
+	var item Sprint
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Sprint{}, ErrItemNotFound
+		}
+
+		return Sprint{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetSprintsByProject(ctx context.Context, projectId string) ([]Sprint, error) {
+	query := SprintQuery().
+		Where(goqu.I("sprints.project_id").Eq(projectId))
+
+	var items []Sprint
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetNextSprint returns the first open sprint of the project that starts
+// after the sprint
+func (db *Database) GetNextSprint(ctx context.Context, sprint Sprint) (Sprint, error) {
+	query := SprintQuery().
+		Where(
+			goqu.I("sprints.project_id").Eq(sprint.ProjectId),
+			goqu.I("sprints.id").Neq(sprint.Id),
+			goqu.I("sprints.closed").IsNull(),
+			goqu.I("sprints.start_date").Gte(sprint.StartDate),
+		).
+		Limit(1)
+
+	var item Sprint
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Sprint{}, ErrItemNotFound
+		}
+
+		return Sprint{}, err
+	}
+
+	return item, nil
+}
+
+type CreateSprintParams struct {
+	Id        string
+	ProjectId string
+
+	Name string
+
+	StartDate int64
+	EndDate   int64
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateSprint(ctx context.Context, params CreateSprintParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateSprintId()
+	}
+
+	query := dialect.Insert("sprints").
+		Rows(goqu.Record{
+			"id":         id,
+			"project_id": params.ProjectId,
+
+			"name": params.Name,
+
+			"start_date": params.StartDate,
+			"end_date":   params.EndDate,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("sprints.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+type SprintChanges struct {
+	Name types.Change[string]
+
+	StartDate types.Change[int64]
+	EndDate   types.Change[int64]
+
+	Closed types.Change[sql.NullInt64]
+}
+
+func (db *Database) UpdateSprint(ctx context.Context, id string, changes SprintChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "name", changes.Name)
+
+	addToRecord(record, "start_date", changes.StartDate)
+	addToRecord(record, "end_date", changes.EndDate)
+
+	addToRecord(record, "closed", changes.Closed)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("sprints").
+		Set(record).
+		Where(goqu.I("sprints.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) DeleteSprint(ctx context.Context, id string) error {
+	query := dialect.Delete("sprints").
+		Prepared(true).
+		Where(goqu.I("sprints.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSprintScopeTasks returns the tasks that is inside the sprint or has
+// been inside the sprint at some point according to the task history
+func (db *Database) GetSprintScopeTasks(ctx context.Context, sprintId string) ([]Task, error) {
+	// NOTE(patrik): "sprint" is the field name used by the task history
+	changes := dialect.From(
+		goqu.T("task_history"),
+		goqu.L("json_each(task_history.changes)").As("changes"),
+	).
+		Select("task_history.task_id").
+		Where(
+			goqu.L("json_extract(changes.value, '$.field')").Eq("sprint"),
+			goqu.Or(
+				goqu.L("json_extract(changes.value, '$.before')").Eq(sprintId),
+				goqu.L("json_extract(changes.value, '$.after')").Eq(sprintId),
+			),
+		)
+
+	query := TaskQuery(TaskFilter{}, TaskSortRank).
+		Where(goqu.Or(
+			goqu.I("tasks.sprint_id").Eq(sprintId),
+			goqu.I("tasks.id").In(changes),
+		))
+
+	var items []Task
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
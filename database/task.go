@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
 	"github.com/mattn/go-sqlite3"
+	"github.com/nanoteck137/beldum/tools/duerange"
+	"github.com/nanoteck137/beldum/tools/rank"
 	"github.com/nanoteck137/beldum/tools/utils"
 	"github.com/nanoteck137/beldum/types"
 )
@@ -15,21 +18,131 @@ import (
 type Task struct {
 	RowId int `db:"rowid"`
 
-	Id    string `db:"id"`
-	Title string `db:"title"`
+	Id          string         `db:"id"`
+	Number      int64          `db:"number"`
+	Title       string         `db:"title"`
+	Description sql.NullString `db:"description"`
 
-	ProjectId string `db:"project_id"`
+	ProjectId  string         `db:"project_id"`
+	ProjectKey string         `db:"project_key"`
+	ParentId   sql.NullString `db:"parent_id"`
 
 	BoardId   string `db:"board_id"`
 	BoardName string `db:"board_name"`
+	Rank      string `db:"rank"`
+
+	Due        sql.NullInt64 `db:"due"`
+	DueHasTime bool          `db:"due_has_time"`
+
+	Priority types.TaskPriority `db:"priority"`
+
+	Estimate    sql.NullInt64  `db:"estimate"`
+	SprintId    sql.NullString `db:"sprint_id"`
+	MilestoneId sql.NullString `db:"milestone_id"`
+
+	Archived sql.NullInt64 `db:"archived"`
+	Moved    int64         `db:"moved"`
 
 	Created int64 `db:"created"`
 	Updated int64 `db:"updated"`
 
 	Tags sql.NullString `db:"tags"`
+
+	ChecklistDone  int64 `db:"checklist_done"`
+	ChecklistTotal int64 `db:"checklist_total"`
+
+	ChildrenDone  int64 `db:"children_done"`
+	ChildrenTotal int64 `db:"children_total"`
+
+	BlockerCount int64 `db:"blocker_count"`
+
+	Fields sql.NullString `db:"fields"`
+
+	Done bool `db:"done"`
+}
+
+type TaskSort string
+
+const (
+	TaskSortRank     TaskSort = "rank"
+	TaskSortTitle    TaskSort = "title"
+	TaskSortPriority TaskSort = "priority"
+	TaskSortCreated  TaskSort = "created"
+	TaskSortUpdated  TaskSort = "updated"
+	TaskSortDue      TaskSort = "due"
+)
+
+func IsValidTaskSort(sort TaskSort) bool {
+	switch sort {
+	case TaskSortRank, TaskSortTitle, TaskSortPriority, TaskSortCreated, TaskSortUpdated, TaskSortDue:
+		return true
+	}
+
+	return false
+}
+
+type TaskArchivedFilter string
+
+const (
+	TaskArchivedInclude TaskArchivedFilter = ""
+	TaskArchivedHide    TaskArchivedFilter = "hide"
+	TaskArchivedOnly    TaskArchivedFilter = "only"
+)
+
+type TaskFilter struct {
+	// NOTE(patrik): Matches the title or the description, case insensitive
+	Search string
+
+	// NOTE(patrik): Defaults to including the archived tasks
+	Archived TaskArchivedFilter
+
+	// NOTE(patrik): Inclusive
+	DueFrom *duerange.Bound
+	// NOTE(patrik): Exclusive
+	DueTo *duerange.Bound
+
+	Fields []TaskFieldFilter
+
+	SprintId    sql.NullString
+	MilestoneId sql.NullString
+}
+
+// TaskFieldFilter matches tasks that has the value set for the custom
+// field, for multi-select fields the value only needs to be one of the
+// selected options
+type TaskFieldFilter struct {
+	FieldId string
+	Value   string
+	Multi   bool
+
+	// NOTE(patrik): Matches the tasks that don't have the value instead,
+	// including the tasks without any value set
+	Not bool
+}
+
+// TaskChildrenSummaryQuery counts the children of every parent task and how
+// many of those children are on the done board of their project
+func TaskChildrenSummaryQuery() *goqu.SelectDataset {
+	query := dialect.From(goqu.T("tasks").As("children")).
+		Select(
+			goqu.I("children.parent_id").As("parent_id"),
+			goqu.SUM(
+				goqu.I("children.board_id").Eq(
+					DoneBoardQuery(goqu.I("children.project_id")),
+				),
+			).As("done"),
+			goqu.COUNT(goqu.I("children.id")).As("total"),
+		).
+		Where(
+			goqu.I("children.parent_id").IsNotNull(),
+			goqu.I("children.trash_id").IsNull(),
+		).
+		GroupBy(goqu.I("children.parent_id"))
+
+	return query
 }
 
-func TaskQuery() *goqu.SelectDataset {
+func TaskQuery(filter TaskFilter, sort TaskSort) *goqu.SelectDataset {
 	tags := dialect.From("tasks_tags").
 		Select(
 			goqu.I("tasks_tags.task_id").As("task_id"),
@@ -49,19 +162,55 @@ func TaskQuery() *goqu.SelectDataset {
 			"tasks.rowid",
 
 			"tasks.id",
+			"tasks.number",
 			"tasks.title",
+			"tasks.description",
 
 			"tasks.project_id",
+			"tasks.parent_id",
 			"tasks.board_id",
+			"tasks.rank",
+
+			"tasks.due",
+			"tasks.due_has_time",
+
+			"tasks.priority",
+
+			"tasks.estimate",
+			"tasks.sprint_id",
+			"tasks.milestone_id",
+
+			"tasks.archived",
+			"tasks.moved",
 
 			"tasks.created",
 			"tasks.updated",
 
+			goqu.I("projects.key").As("project_key"),
+
 			goqu.I("boards.name").As("board_name"),
 
 			goqu.I("tags.tags").As("tags"),
+
+			goqu.COALESCE(goqu.I("checklists.done"), 0).As("checklist_done"),
+			goqu.COALESCE(goqu.I("checklists.total"), 0).As("checklist_total"),
+
+			goqu.COALESCE(goqu.I("children.done"), 0).As("children_done"),
+			goqu.COALESCE(goqu.I("children.total"), 0).As("children_total"),
+
+			goqu.COALESCE(goqu.I("blockers.count"), 0).As("blocker_count"),
+
+			goqu.I("fields.fields").As("fields"),
+
+			goqu.I("tasks.board_id").Eq(
+				DoneBoardQuery(goqu.I("tasks.project_id")),
+			).As("done"),
 		).
 		Prepared(true).
+		Join(
+			goqu.I("projects"),
+			goqu.On(goqu.I("tasks.project_id").Eq(goqu.I("projects.id"))),
+		).
 		Join(
 			goqu.I("boards"),
 			goqu.On(goqu.I("tasks.board_id").Eq(goqu.I("boards.id"))),
@@ -70,13 +219,121 @@ func TaskQuery() *goqu.SelectDataset {
 			tags.As("tags"),
 			goqu.On(goqu.I("tasks.id").Eq(goqu.I("tags.task_id"))),
 		).
-		Order(goqu.I("tasks.title").Asc())
+		LeftJoin(
+			TaskChecklistSummaryQuery().As("checklists"),
+			goqu.On(goqu.I("tasks.id").Eq(goqu.I("checklists.task_id"))),
+		).
+		LeftJoin(
+			TaskChildrenSummaryQuery().As("children"),
+			goqu.On(goqu.I("tasks.id").Eq(goqu.I("children.parent_id"))),
+		).
+		LeftJoin(
+			TaskBlockersQuery().As("blockers"),
+			goqu.On(goqu.I("tasks.id").Eq(goqu.I("blockers.task_id"))),
+		).
+		LeftJoin(
+			TaskFieldValuesQuery().As("fields"),
+			goqu.On(goqu.I("tasks.id").Eq(goqu.I("fields.task_id"))),
+		).
+		Where(goqu.I("tasks.trash_id").IsNull())
+
+	switch sort {
+	case TaskSortPriority:
+		query = query.Order(goqu.I("tasks.priority").Desc(), goqu.I("tasks.title").Asc())
+	case TaskSortCreated:
+		query = query.Order(goqu.I("tasks.created").Desc(), goqu.I("tasks.title").Asc())
+	case TaskSortUpdated:
+		query = query.Order(goqu.I("tasks.updated").Desc(), goqu.I("tasks.title").Asc())
+	case TaskSortDue:
+		query = query.Order(goqu.I("tasks.due").Asc().NullsLast(), goqu.I("tasks.title").Asc())
+	case TaskSortTitle:
+		query = query.Order(goqu.I("tasks.title").Asc())
+	default:
+		query = query.Order(goqu.I("tasks.rank").Asc(), goqu.I("tasks.title").Asc())
+	}
+
+	if filter.Search != "" {
+		search := strings.ToLower(filter.Search)
+		query = query.Where(goqu.Or(
+			goqu.L("instr(lower(?), ?) > 0", goqu.I("tasks.title"), search),
+			goqu.L("instr(lower(?), ?) > 0", goqu.I("tasks.description"), search),
+		))
+	}
+
+	switch filter.Archived {
+	case TaskArchivedHide:
+		query = query.Where(goqu.I("tasks.archived").IsNull())
+	case TaskArchivedOnly:
+		query = query.Where(goqu.I("tasks.archived").IsNotNull())
+	}
+
+	// NOTE(patrik): Due dates with and without time is compared against
+	// different values, see duerange
+	if filter.DueFrom != nil {
+		query = query.Where(goqu.Or(
+			goqu.And(
+				goqu.I("tasks.due_has_time").IsTrue(),
+				goqu.I("tasks.due").Gte(filter.DueFrom.Value(true)),
+			),
+			goqu.And(
+				goqu.I("tasks.due_has_time").IsFalse(),
+				goqu.I("tasks.due").Gte(filter.DueFrom.Value(false)),
+			),
+		))
+	}
+
+	if filter.DueTo != nil {
+		query = query.Where(goqu.Or(
+			goqu.And(
+				goqu.I("tasks.due_has_time").IsTrue(),
+				goqu.I("tasks.due").Lt(filter.DueTo.Value(true)),
+			),
+			goqu.And(
+				goqu.I("tasks.due_has_time").IsFalse(),
+				goqu.I("tasks.due").Lt(filter.DueTo.Value(false)),
+			),
+		))
+	}
+
+	if filter.SprintId.Valid {
+		query = query.Where(goqu.I("tasks.sprint_id").Eq(filter.SprintId.String))
+	}
+
+	if filter.MilestoneId.Valid {
+		query = query.Where(goqu.I("tasks.milestone_id").Eq(filter.MilestoneId.String))
+	}
+
+	for _, field := range filter.Fields {
+		values := dialect.From("task_field_values").
+			Select(goqu.L("1")).
+			Where(
+				goqu.I("task_field_values.task_id").Eq(goqu.I("tasks.id")),
+				goqu.I("task_field_values.field_id").Eq(field.FieldId),
+			)
+
+		if field.Multi {
+			values = values.
+				Join(
+					goqu.L("json_each(task_field_values.value)").As("options"),
+					goqu.On(goqu.I("options.value").Eq(field.Value)),
+				)
+		} else {
+			values = values.
+				Where(goqu.I("task_field_values.value").Eq(field.Value))
+		}
+
+		if field.Not {
+			query = query.Where(goqu.L("NOT EXISTS ?", values))
+		} else {
+			query = query.Where(goqu.L("EXISTS ?", values))
+		}
+	}
 
 	return query
 }
 
 func (db *Database) GetAllTasks(ctx context.Context) ([]Task, error) {
-	query := TaskQuery()
+	query := TaskQuery(TaskFilter{}, TaskSortTitle)
 
 	var items []Task
 	err := db.Select(&items, query)
@@ -88,7 +345,7 @@ func (db *Database) GetAllTasks(ctx context.Context) ([]Task, error) {
 }
 
 func (db *Database) GetTaskById(ctx context.Context, id string) (Task, error) {
-	query := TaskQuery().
+	query := TaskQuery(TaskFilter{}, TaskSortTitle).
 		Where(goqu.I("tasks.id").Eq(id))
 
 	var item Task
@@ -104,8 +361,31 @@ func (db *Database) GetTaskById(ctx context.Context, id string) (Task, error) {
 	return item, nil
 }
 
-func (db *Database) GetTasksByBoard(ctx context.Context, boardId string) ([]Task, error) {
-	query := TaskQuery().
+// GetTaskByKey finds the task from the KEY-N identifier, project keys are
+// only unique per user so the owner is needed for the lookup
+func (db *Database) GetTaskByKey(ctx context.Context, ownerId, projectKey string, number int64) (Task, error) {
+	query := TaskQuery(TaskFilter{}, TaskSortTitle).
+		Where(
+			goqu.I("projects.owner_id").Eq(ownerId),
+			goqu.I("projects.key").Eq(projectKey),
+			goqu.I("tasks.number").Eq(number),
+		)
+
+	var item Task
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Task{}, ErrItemNotFound
+		}
+
+		return Task{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTasksByBoard(ctx context.Context, boardId string, filter TaskFilter, sort TaskSort) ([]Task, error) {
+	query := TaskQuery(filter, sort).
 		Where(goqu.I("tasks.board_id").Eq(boardId))
 
 	var items []Task
@@ -117,8 +397,21 @@ func (db *Database) GetTasksByBoard(ctx context.Context, boardId string) ([]Task
 	return items, nil
 }
 
-func (db *Database) GetTasksByProject(ctx context.Context, projectId string) ([]Task, error) {
-	query := TaskQuery().
+func (db *Database) GetTaskChildren(ctx context.Context, parentId string) ([]Task, error) {
+	query := TaskQuery(TaskFilter{}, TaskSortTitle).
+		Where(goqu.I("tasks.parent_id").Eq(parentId))
+
+	var items []Task
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (db *Database) GetTasksByProject(ctx context.Context, projectId string, filter TaskFilter, sort TaskSort) ([]Task, error) {
+	query := TaskQuery(filter, sort).
 		Where(goqu.I("tasks.project_id").Eq(projectId))
 
 	var items []Task
@@ -131,17 +424,54 @@ func (db *Database) GetTasksByProject(ctx context.Context, projectId string) ([]
 }
 
 type CreateTaskParams struct {
-	Id    string
-	Title string
+	Id          string
+	Title       string
+	Description sql.NullString
 
 	ProjectId string
+	ParentId  sql.NullString
 	BoardId   string
 
+	// NOTE(patrik): Empty places the task last on the board
+	Rank string
+
+	Due        sql.NullInt64
+	DueHasTime bool
+
+	Priority types.TaskPriority
+
+	Estimate    sql.NullInt64
+	SprintId    sql.NullString
+	MilestoneId sql.NullString
+
 	Created int64
 	Updated int64
 }
 
+// CreateTask creates the task with the next number of the project, the
+// number and the insert is always done inside the same transaction so
+// concurrent creates can't end up with the same number
 func (db *Database) CreateTask(ctx context.Context, params CreateTaskParams) (Task, error) {
+	if !db.InTransaction() {
+		tdb, tx, err := db.Begin()
+		if err != nil {
+			return Task{}, err
+		}
+		defer tx.Rollback()
+
+		task, err := tdb.CreateTask(ctx, params)
+		if err != nil {
+			return Task{}, err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return Task{}, err
+		}
+
+		return task, nil
+	}
+
 	t := time.Now().UnixMilli()
 	created := params.Created
 	updated := params.Updated
@@ -156,23 +486,67 @@ func (db *Database) CreateTask(ctx context.Context, params CreateTaskParams) (Ta
 		id = utils.CreateTaskId()
 	}
 
+	number, err := db.NextTaskNumber(ctx, params.ProjectId)
+	if err != nil {
+		return Task{}, err
+	}
+
+	taskRank := params.Rank
+	if taskRank == "" {
+		taskRank, err = db.LastTaskRank(ctx, params.BoardId)
+		if err != nil {
+			return Task{}, err
+		}
+	}
+
 	query := dialect.Insert("tasks").
 		Rows(goqu.Record{
-			"id":    id,
-			"title": params.Title,
+			"id":          id,
+			"number":      number,
+			"title":       params.Title,
+			"description": params.Description,
 
 			"project_id": params.ProjectId,
+			"parent_id":  params.ParentId,
 			"board_id":   params.BoardId,
+			"rank":       taskRank,
+
+			"due":          params.Due,
+			"due_has_time": params.DueHasTime,
+
+			"priority": params.Priority,
+
+			"estimate":     params.Estimate,
+			"sprint_id":    params.SprintId,
+			"milestone_id": params.MilestoneId,
+
+			"moved": created,
 
 			"created": created,
 			"updated": updated,
 		}).
 		Returning(
 			"tasks.id",
+			"tasks.number",
 			"tasks.title",
+			"tasks.description",
 
 			"tasks.project_id",
+			"tasks.parent_id",
 			"tasks.board_id",
+			"tasks.rank",
+
+			"tasks.due",
+			"tasks.due_has_time",
+
+			"tasks.priority",
+
+			"tasks.estimate",
+			"tasks.sprint_id",
+			"tasks.milestone_id",
+
+			"tasks.archived",
+			"tasks.moved",
 
 			"tasks.created",
 			"tasks.updated",
@@ -180,7 +554,7 @@ func (db *Database) CreateTask(ctx context.Context, params CreateTaskParams) (Ta
 		Prepared(true)
 
 	var item Task
-	err := db.Get(&item, query)
+	err = db.Get(&item, query)
 	if err != nil {
 		return Task{}, err
 	}
@@ -202,10 +576,26 @@ func (db *Database) DeleteTask(ctx context.Context, id string) error {
 }
 
 type TaskChanges struct {
-	Title types.Change[string]
+	Title       types.Change[string]
+	Description types.Change[sql.NullString]
 
+	// NOTE(patrik): The number needs to change together with the project
+	Number    types.Change[int64]
 	ProjectId types.Change[string]
+	ParentId  types.Change[sql.NullString]
 	BoardId   types.Change[string]
+	Rank      types.Change[string]
+
+	Due        types.Change[sql.NullInt64]
+	DueHasTime types.Change[bool]
+
+	Priority types.Change[types.TaskPriority]
+
+	Estimate    types.Change[sql.NullInt64]
+	SprintId    types.Change[sql.NullString]
+	MilestoneId types.Change[sql.NullString]
+
+	Archived types.Change[sql.NullInt64]
 
 	Created types.Change[int64]
 }
@@ -214,9 +604,24 @@ func (db *Database) UpdateTask(ctx context.Context, id string, changes TaskChang
 	record := goqu.Record{}
 
 	addToRecord(record, "title", changes.Title)
+	addToRecord(record, "description", changes.Description)
 
+	addToRecord(record, "number", changes.Number)
 	addToRecord(record, "project_id", changes.ProjectId)
+	addToRecord(record, "parent_id", changes.ParentId)
 	addToRecord(record, "board_id", changes.BoardId)
+	addToRecord(record, "rank", changes.Rank)
+
+	addToRecord(record, "due", changes.Due)
+	addToRecord(record, "due_has_time", changes.DueHasTime)
+
+	addToRecord(record, "priority", changes.Priority)
+
+	addToRecord(record, "estimate", changes.Estimate)
+	addToRecord(record, "sprint_id", changes.SprintId)
+	addToRecord(record, "milestone_id", changes.MilestoneId)
+
+	addToRecord(record, "archived", changes.Archived)
 
 	addToRecord(record, "created", changes.Created)
 
@@ -224,7 +629,12 @@ func (db *Database) UpdateTask(ctx context.Context, id string, changes TaskChang
 		return nil
 	}
 
-	record["updated"] = time.Now().UnixMilli()
+	t := time.Now().UnixMilli()
+	record["updated"] = t
+
+	if changes.BoardId.Changed {
+		record["moved"] = t
+	}
 
 	ds := dialect.Update("tasks").
 		Set(record).
@@ -239,6 +649,77 @@ func (db *Database) UpdateTask(ctx context.Context, id string, changes TaskChang
 	return nil
 }
 
+// LastTaskRank creates a rank that places a task after every other task on
+// the board
+func (db *Database) LastTaskRank(ctx context.Context, boardId string) (string, error) {
+	query := dialect.From("tasks").
+		Prepared(true).
+		Select(goqu.MAX("tasks.rank")).
+		Where(goqu.I("tasks.board_id").Eq(boardId))
+
+	var last sql.NullString
+	err := db.Get(&last, query)
+	if err != nil {
+		return "", err
+	}
+
+	return rank.Between(last.String, "")
+}
+
+// TaskRankNeighbour returns the rank of the closest task on the board
+// before or after the rank, the excluded task is skipped so a task can be
+// moved relative to its own neighbours. An empty string is returned if
+// there is no task.
+func (db *Database) TaskRankNeighbour(ctx context.Context, boardId, taskRank, excludeId string, before bool) (string, error) {
+	query := dialect.From("tasks").
+		Prepared(true).
+		Where(
+			goqu.I("tasks.board_id").Eq(boardId),
+			goqu.I("tasks.id").Neq(excludeId),
+		)
+
+	if before {
+		query = query.
+			Select(goqu.MAX("tasks.rank")).
+			Where(goqu.I("tasks.rank").Lt(taskRank))
+	} else {
+		query = query.
+			Select(goqu.MIN("tasks.rank")).
+			Where(goqu.I("tasks.rank").Gt(taskRank))
+	}
+
+	var item sql.NullString
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item.String, nil
+}
+
+// GetAutoArchiveTasks returns the tasks that has been on the done board
+// longer than the auto archive days of the project, projects without the
+// setting is skipped
+func (db *Database) GetAutoArchiveTasks(ctx context.Context, now int64) ([]Task, error) {
+	days := dialect.From("projects").
+		Select(goqu.I("projects.auto_archive_days")).
+		Where(goqu.I("projects.id").Eq(goqu.I("tasks.project_id")))
+
+	query := TaskQuery(TaskFilter{Archived: TaskArchivedHide}, TaskSortCreated).
+		Where(
+			goqu.I("tasks.board_id").Eq(DoneBoardQuery(goqu.I("tasks.project_id"))),
+			goqu.L("? <= ? - ? * ?", goqu.I("tasks.moved"), now, days, 24*60*60*1000),
+		)
+
+	var items []Task
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
 // TODO(patrik): Generalize
 func (db *Database) AddTaskTag(ctx context.Context, taskId, projectId, tagSlug string) error {
 	ds := dialect.Insert("tasks_tags").
@@ -277,3 +758,43 @@ func (db *Database) RemoveAllTaskTags(ctx context.Context, taskId string) error
 
 	return nil
 }
+
+// TODO(patrik): Generalize
+func (db *Database) RemoveTaskTag(ctx context.Context, taskId, projectId, tagSlug string) error {
+	query := dialect.Delete("tasks_tags").
+		Prepared(true).
+		Where(
+			goqu.I("tasks_tags.task_id").Eq(taskId),
+			goqu.I("tasks_tags.project_id").Eq(projectId),
+			goqu.I("tasks_tags.tag_slug").Eq(tagSlug),
+		)
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DetachTrashedChildren clears the parent of the trashed tasks below the
+// parents, used when the parents leave the project without the trashed
+// tasks
+func (db *Database) DetachTrashedChildren(ctx context.Context, parentIds []string) error {
+	query := dialect.Update("tasks").
+		Prepared(true).
+		Set(goqu.Record{
+			"parent_id": nil,
+		}).
+		Where(
+			goqu.I("tasks.parent_id").In(parentIds),
+			goqu.I("tasks.trash_id").IsNotNull(),
+		)
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TaskChecklist struct {
+	RowId int `db:"rowid"`
+
+	Id     string `db:"id"`
+	TaskId string `db:"task_id"`
+
+	Name string `db:"name"`
+
+	OrderNumber int64 `db:"order_number"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+}
+
+type TaskChecklistItem struct {
+	RowId int `db:"rowid"`
+
+	Id          string `db:"id"`
+	ChecklistId string `db:"checklist_id"`
+
+	Title   string `db:"title"`
+	Checked bool   `db:"checked"`
+
+	OrderNumber int64 `db:"order_number"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+}
+
+func TaskChecklistQuery() *goqu.SelectDataset {
+	query := dialect.From("task_checklists").
+		Select(
+			"task_checklists.rowid",
+
+			"task_checklists.id",
+			"task_checklists.task_id",
+
+			"task_checklists.name",
+
+			"task_checklists.order_number",
+
+			"task_checklists.created",
+			"task_checklists.updated",
+		).
+		Prepared(true).
+		Order(goqu.I("task_checklists.order_number").Asc())
+
+	return query
+}
+
+func TaskChecklistItemQuery() *goqu.SelectDataset {
+	query := dialect.From("task_checklist_items").
+		Select(
+			"task_checklist_items.rowid",
+
+			"task_checklist_items.id",
+			"task_checklist_items.checklist_id",
+
+			"task_checklist_items.title",
+			"task_checklist_items.checked",
+
+			"task_checklist_items.order_number",
+
+			"task_checklist_items.created",
+			"task_checklist_items.updated",
+		).
+		Prepared(true).
+		Order(goqu.I("task_checklist_items.order_number").Asc())
+
+	return query
+}
+
+// TaskChecklistSummaryQuery counts the checked and total items of all the
+// checklists for every task that has any checklist items
+func TaskChecklistSummaryQuery() *goqu.SelectDataset {
+	query := dialect.From("task_checklist_items").
+		Select(
+			goqu.I("task_checklists.task_id").As("task_id"),
+			goqu.SUM(goqu.I("task_checklist_items.checked")).As("done"),
+			goqu.COUNT(goqu.I("task_checklist_items.id")).As("total"),
+		).
+		Join(
+			goqu.I("task_checklists"),
+			goqu.On(goqu.I("task_checklist_items.checklist_id").Eq(goqu.I("task_checklists.id"))),
+		).
+		GroupBy(goqu.I("task_checklists.task_id"))
+
+	return query
+}
+
+func (db *Database) GetTaskChecklistById(ctx context.Context, id string) (TaskChecklist, error) {
+	query := TaskChecklistQuery().
+		Where(goqu.I("task_checklists.id").Eq(id))
+
+	var item TaskChecklist
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskChecklist{}, ErrItemNotFound
+		}
+
+		return TaskChecklist{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskChecklists(ctx context.Context, taskId string) ([]TaskChecklist, error) {
+	query := TaskChecklistQuery().
+		Where(goqu.I("task_checklists.task_id").Eq(taskId))
+
+	var items []TaskChecklist
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (db *Database) GetTaskChecklistItemById(ctx context.Context, id string) (TaskChecklistItem, error) {
+	query := TaskChecklistItemQuery().
+		Where(goqu.I("task_checklist_items.id").Eq(id))
+
+	var item TaskChecklistItem
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskChecklistItem{}, ErrItemNotFound
+		}
+
+		return TaskChecklistItem{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskChecklistItems(ctx context.Context, checklistId string) ([]TaskChecklistItem, error) {
+	query := TaskChecklistItemQuery().
+		Where(goqu.I("task_checklist_items.checklist_id").Eq(checklistId))
+
+	var items []TaskChecklistItem
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTaskChecklistParams struct {
+	Id     string
+	TaskId string
+
+	Name string
+
+	OrderNumber int64
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTaskChecklist(ctx context.Context, params CreateTaskChecklistParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateChecklistId()
+	}
+
+	query := dialect.Insert("task_checklists").
+		Rows(goqu.Record{
+			"id":      id,
+			"task_id": params.TaskId,
+
+			"name": params.Name,
+
+			"order_number": params.OrderNumber,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("task_checklists.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+func (db *Database) DeleteTaskChecklist(ctx context.Context, id string) error {
+	query := dialect.Delete("task_checklists").
+		Prepared(true).
+		Where(goqu.I("task_checklists.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type TaskChecklistChanges struct {
+	Name types.Change[string]
+
+	OrderNumber types.Change[int64]
+}
+
+func (db *Database) UpdateTaskChecklist(ctx context.Context, id string, changes TaskChecklistChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "name", changes.Name)
+
+	addToRecord(record, "order_number", changes.OrderNumber)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("task_checklists").
+		Set(record).
+		Where(goqu.I("task_checklists.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type CreateTaskChecklistItemParams struct {
+	Id          string
+	ChecklistId string
+
+	Title   string
+	Checked bool
+
+	OrderNumber int64
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTaskChecklistItem(ctx context.Context, params CreateTaskChecklistItemParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateChecklistItemId()
+	}
+
+	query := dialect.Insert("task_checklist_items").
+		Rows(goqu.Record{
+			"id":           id,
+			"checklist_id": params.ChecklistId,
+
+			"title":   params.Title,
+			"checked": params.Checked,
+
+			"order_number": params.OrderNumber,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("task_checklist_items.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+func (db *Database) DeleteTaskChecklistItem(ctx context.Context, id string) error {
+	query := dialect.Delete("task_checklist_items").
+		Prepared(true).
+		Where(goqu.I("task_checklist_items.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type TaskChecklistItemChanges struct {
+	Title   types.Change[string]
+	Checked types.Change[bool]
+
+	OrderNumber types.Change[int64]
+}
+
+func (db *Database) UpdateTaskChecklistItem(ctx context.Context, id string, changes TaskChecklistItemChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "title", changes.Title)
+	addToRecord(record, "checked", changes.Checked)
+
+	addToRecord(record, "order_number", changes.OrderNumber)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("task_checklist_items").
+		Set(record).
+		Where(goqu.I("task_checklist_items.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
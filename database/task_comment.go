@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TaskComment struct {
+	RowId int `db:"rowid"`
+
+	Id string `db:"id"`
+
+	TaskId   string         `db:"task_id"`
+	ParentId sql.NullString `db:"parent_id"`
+	UserId   string         `db:"user_id"`
+
+	Content string `db:"content"`
+
+	Edited sql.NullInt64 `db:"edited"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	Username        string         `db:"username"`
+	UserDisplayName sql.NullString `db:"user_display_name"`
+}
+
+func TaskCommentQuery() *goqu.SelectDataset {
+	query := dialect.From("task_comments").
+		Select(
+			"task_comments.rowid",
+
+			"task_comments.id",
+
+			"task_comments.task_id",
+			"task_comments.parent_id",
+			"task_comments.user_id",
+
+			"task_comments.content",
+
+			"task_comments.edited",
+
+			"task_comments.created",
+			"task_comments.updated",
+
+			goqu.I("users.username").As("username"),
+			goqu.I("users_settings.display_name").As("user_display_name"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("users"),
+			goqu.On(goqu.I("task_comments.user_id").Eq(goqu.I("users.id"))),
+		).
+		LeftJoin(
+			goqu.I("users_settings"),
+			goqu.On(goqu.I("task_comments.user_id").Eq(goqu.I("users_settings.id"))),
+		).
+		Order(goqu.I("task_comments.created").Asc())
+
+	return query
+}
+
+func (db *Database) GetTaskCommentById(ctx context.Context, id string) (TaskComment, error) {
+	query := TaskCommentQuery().
+		Where(goqu.I("task_comments.id").Eq(id))
+
+	var item TaskComment
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskComment{}, ErrItemNotFound
+		}
+
+		return TaskComment{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskComments(ctx context.Context, taskId string) ([]TaskComment, error) {
+	query := TaskCommentQuery().
+		Where(goqu.I("task_comments.task_id").Eq(taskId))
+
+	var items []TaskComment
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTaskCommentParams struct {
+	Id string
+
+	TaskId   string
+	ParentId sql.NullString
+	UserId   string
+
+	Content string
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTaskComment(ctx context.Context, params CreateTaskCommentParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateCommentId()
+	}
+
+	query := dialect.Insert("task_comments").
+		Rows(goqu.Record{
+			"id": id,
+
+			"task_id":   params.TaskId,
+			"parent_id": params.ParentId,
+			"user_id":   params.UserId,
+
+			"content": params.Content,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("task_comments.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+func (db *Database) DeleteTaskComment(ctx context.Context, id string) error {
+	query := dialect.Delete("task_comments").
+		Prepared(true).
+		Where(goqu.I("task_comments.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type TaskCommentChanges struct {
+	Content types.Change[string]
+
+	Edited types.Change[sql.NullInt64]
+}
+
+func (db *Database) UpdateTaskComment(ctx context.Context, id string, changes TaskCommentChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "content", changes.Content)
+
+	addToRecord(record, "edited", changes.Edited)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("task_comments").
+		Set(record).
+		Where(goqu.I("task_comments.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+)
+
+type TaskHistoryChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type TaskHistory struct {
+	RowId int `db:"rowid"`
+
+	Id string `db:"id"`
+
+	TaskId string `db:"task_id"`
+	UserId string `db:"user_id"`
+
+	Changes string `db:"changes"`
+
+	Created int64 `db:"created"`
+
+	Username        string         `db:"username"`
+	UserDisplayName sql.NullString `db:"user_display_name"`
+}
+
+func (h TaskHistory) DecodeChanges() ([]TaskHistoryChange, error) {
+	var changes []TaskHistoryChange
+	err := json.Unmarshal([]byte(h.Changes), &changes)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func TaskHistoryQuery() *goqu.SelectDataset {
+	query := dialect.From("task_history").
+		Select(
+			"task_history.rowid",
+
+			"task_history.id",
+
+			"task_history.task_id",
+			"task_history.user_id",
+
+			"task_history.changes",
+
+			"task_history.created",
+
+			goqu.I("users.username").As("username"),
+			goqu.I("users_settings.display_name").As("user_display_name"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("users"),
+			goqu.On(goqu.I("task_history.user_id").Eq(goqu.I("users.id"))),
+		).
+		LeftJoin(
+			goqu.I("users_settings"),
+			goqu.On(goqu.I("task_history.user_id").Eq(goqu.I("users_settings.id"))),
+		).
+		Order(
+			goqu.I("task_history.created").Desc(),
+			goqu.I("task_history.rowid").Desc(),
+		)
+
+	return query
+}
+
+func (db *Database) GetTaskHistoryById(ctx context.Context, id string) (TaskHistory, error) {
+	query := TaskHistoryQuery().
+		Where(goqu.I("task_history.id").Eq(id))
+
+	var item TaskHistory
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskHistory{}, ErrItemNotFound
+		}
+
+		return TaskHistory{}, err
+	}
+
+	return item, nil
+}
+
+// GetTaskHistory returns the history of the task with the newest entry first
+func (db *Database) GetTaskHistory(ctx context.Context, taskId string) ([]TaskHistory, error) {
+	query := TaskHistoryQuery().
+		Where(goqu.I("task_history.task_id").Eq(taskId))
+
+	var items []TaskHistory
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTaskHistoryParams struct {
+	Id string
+
+	TaskId string
+	UserId string
+
+	Changes []TaskHistoryChange
+
+	Created int64
+}
+
+func (db *Database) CreateTaskHistory(ctx context.Context, params CreateTaskHistoryParams) (string, error) {
+	created := params.Created
+	if created == 0 {
+		created = time.Now().UnixMilli()
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateHistoryId()
+	}
+
+	changes, err := json.Marshal(params.Changes)
+	if err != nil {
+		return "", err
+	}
+
+	query := dialect.Insert("task_history").
+		Rows(goqu.Record{
+			"id": id,
+
+			"task_id": params.TaskId,
+			"user_id": params.UserId,
+
+			"changes": string(changes),
+
+			"created": created,
+		}).
+		Returning("task_history.id").
+		Prepared(true)
+
+	var item string
+	err = db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+// GetTaskHistoryByTasks returns the history of the tasks with the newest
+// entry first
+func (db *Database) GetTaskHistoryByTasks(ctx context.Context, taskIds []string) ([]TaskHistory, error) {
+	if len(taskIds) == 0 {
+		return nil, nil
+	}
+
+	query := TaskHistoryQuery().
+		Where(goqu.I("task_history.task_id").In(taskIds))
+
+	var items []TaskHistory
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+type TaskReference struct {
+	TaskId    string         `db:"task_id"`
+	CommentId sql.NullString `db:"comment_id"`
+	TargetId  string         `db:"target_id"`
+
+	Created int64 `db:"created"`
+
+	TaskTitle  string `db:"task_title"`
+	TaskNumber int64  `db:"task_number"`
+	ProjectId  string `db:"project_id"`
+	ProjectKey string `db:"project_key"`
+}
+
+func TaskReferenceQuery() *goqu.SelectDataset {
+	query := dialect.From("task_references").
+		Select(
+			"task_references.task_id",
+			"task_references.comment_id",
+			"task_references.target_id",
+
+			"task_references.created",
+
+			goqu.I("tasks.title").As("task_title"),
+			goqu.I("tasks.number").As("task_number"),
+			goqu.I("tasks.project_id").As("project_id"),
+			goqu.I("projects.key").As("project_key"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("tasks"),
+			goqu.On(goqu.I("task_references.task_id").Eq(goqu.I("tasks.id"))),
+		).
+		Join(
+			goqu.I("projects"),
+			goqu.On(goqu.I("tasks.project_id").Eq(goqu.I("projects.id"))),
+		).
+		Where(goqu.I("tasks.trash_id").IsNull()).
+		Order(goqu.I("task_references.created").Desc())
+
+	return query
+}
+
+// GetTaskBacklinks returns the references pointing to the task, the
+// references from trashed tasks is not included
+func (db *Database) GetTaskBacklinks(ctx context.Context, targetId string) ([]TaskReference, error) {
+	query := TaskReferenceQuery().
+		Where(goqu.I("task_references.target_id").Eq(targetId))
+
+	var items []TaskReference
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SetTaskReferences replaces the references made by the title and the
+// description of the task, or by the comment when the comment id is set.
+// References that still exists keeps the original created time.
+func (db *Database) SetTaskReferences(ctx context.Context, taskId string, commentId sql.NullString, targetIds []string) error {
+	source := goqu.I("task_references.comment_id").IsNull()
+	if commentId.Valid {
+		source = goqu.I("task_references.comment_id").Eq(commentId.String)
+	}
+
+	del := dialect.Delete("task_references").
+		Prepared(true).
+		Where(
+			goqu.I("task_references.task_id").Eq(taskId),
+			source,
+		)
+
+	if len(targetIds) > 0 {
+		del = del.Where(goqu.I("task_references.target_id").NotIn(targetIds))
+	}
+
+	_, err := db.Exec(ctx, del)
+	if err != nil {
+		return err
+	}
+
+	if len(targetIds) == 0 {
+		return nil
+	}
+
+	t := time.Now().UnixMilli()
+
+	rows := make([]any, len(targetIds))
+	for i, targetId := range targetIds {
+		rows[i] = goqu.Record{
+			"task_id":    taskId,
+			"comment_id": commentId,
+			"target_id":  targetId,
+			"created":    t,
+		}
+	}
+
+	query := dialect.Insert("task_references").
+		Prepared(true).
+		Rows(rows...).
+		OnConflict(goqu.DoNothing())
+
+	_, err = db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
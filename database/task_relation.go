@@ -0,0 +1,246 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/mattn/go-sqlite3"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TaskRelation struct {
+	RowId int `db:"rowid"`
+
+	Id        string `db:"id"`
+	ProjectId string `db:"project_id"`
+
+	FromTaskId string                 `db:"from_task_id"`
+	ToTaskId   string                 `db:"to_task_id"`
+	Type       types.TaskRelationType `db:"type"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	FromTaskTitle string `db:"from_task_title"`
+	ToTaskTitle   string `db:"to_task_title"`
+}
+
+func TaskRelationQuery() *goqu.SelectDataset {
+	query := dialect.From("task_relations").
+		Select(
+			"task_relations.rowid",
+
+			"task_relations.id",
+			"task_relations.project_id",
+
+			"task_relations.from_task_id",
+			"task_relations.to_task_id",
+			"task_relations.type",
+
+			"task_relations.created",
+			"task_relations.updated",
+
+			goqu.I("from_tasks.title").As("from_task_title"),
+			goqu.I("to_tasks.title").As("to_task_title"),
+		).
+		Prepared(true).
+		Join(
+			goqu.T("tasks").As("from_tasks"),
+			goqu.On(goqu.I("task_relations.from_task_id").Eq(goqu.I("from_tasks.id"))),
+		).
+		Join(
+			goqu.T("tasks").As("to_tasks"),
+			goqu.On(goqu.I("task_relations.to_task_id").Eq(goqu.I("to_tasks.id"))),
+		).
+		Where(
+			goqu.I("from_tasks.trash_id").IsNull(),
+			goqu.I("to_tasks.trash_id").IsNull(),
+		).
+		Order(goqu.I("task_relations.created").Asc())
+
+	return query
+}
+
+// TaskBlockersQuery counts the blocking tasks for every blocked task,
+// blocking tasks that are on the done board are not counted
+func TaskBlockersQuery() *goqu.SelectDataset {
+	query := dialect.From("task_relations").
+		Select(
+			goqu.I("task_relations.to_task_id").As("task_id"),
+			goqu.COUNT(goqu.I("task_relations.id")).As("count"),
+		).
+		Join(
+			goqu.T("tasks").As("blockers"),
+			goqu.On(goqu.I("task_relations.from_task_id").Eq(goqu.I("blockers.id"))),
+		).
+		Where(
+			goqu.I("task_relations.type").Eq(types.TaskRelationBlocks),
+			goqu.I("blockers.trash_id").IsNull(),
+			goqu.I("blockers.board_id").Neq(
+				DoneBoardQuery(goqu.I("blockers.project_id")),
+			),
+		).
+		GroupBy(goqu.I("task_relations.to_task_id"))
+
+	return query
+}
+
+func (db *Database) GetTaskRelationById(ctx context.Context, id string) (TaskRelation, error) {
+	query := TaskRelationQuery().
+		Where(goqu.I("task_relations.id").Eq(id))
+
+	var item TaskRelation
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskRelation{}, ErrItemNotFound
+		}
+
+		return TaskRelation{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskRelationsByTask(ctx context.Context, taskId string) ([]TaskRelation, error) {
+	query := TaskRelationQuery().
+		Where(
+			goqu.Or(
+				goqu.I("task_relations.from_task_id").Eq(taskId),
+				goqu.I("task_relations.to_task_id").Eq(taskId),
+			),
+		)
+
+	var items []TaskRelation
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (db *Database) GetTaskRelationsByProject(ctx context.Context, projectId string) ([]TaskRelation, error) {
+	query := TaskRelationQuery().
+		Where(goqu.I("task_relations.project_id").Eq(projectId))
+
+	var items []TaskRelation
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTaskRelationParams struct {
+	Id        string
+	ProjectId string
+
+	FromTaskId string
+	ToTaskId   string
+	Type       types.TaskRelationType
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTaskRelation(ctx context.Context, params CreateTaskRelationParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateRelationId()
+	}
+
+	query := dialect.Insert("task_relations").
+		Rows(goqu.Record{
+			"id":         id,
+			"project_id": params.ProjectId,
+
+			"from_task_id": params.FromTaskId,
+			"to_task_id":   params.ToTaskId,
+			"type":         params.Type,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("task_relations.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		var e sqlite3.Error
+		if errors.As(err, &e) {
+			if e.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return "", ErrItemAlreadyExists
+			}
+		}
+
+		return "", err
+	}
+
+	return item, nil
+}
+
+func (db *Database) DeleteTaskRelation(ctx context.Context, id string) error {
+	query := dialect.Delete("task_relations").
+		Prepared(true).
+		Where(goqu.I("task_relations.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MoveTaskRelations moves the relations between the tasks to the project,
+// relations between one of the tasks and a task outside of the tasks is
+// deleted because relations can't cross projects
+func (db *Database) MoveTaskRelations(ctx context.Context, taskIds []string, projectId string) error {
+	from := goqu.I("task_relations.from_task_id")
+	to := goqu.I("task_relations.to_task_id")
+
+	del := dialect.Delete("task_relations").
+		Prepared(true).
+		Where(
+			goqu.Or(
+				goqu.And(from.In(taskIds), to.NotIn(taskIds)),
+				goqu.And(from.NotIn(taskIds), to.In(taskIds)),
+			),
+		)
+
+	_, err := db.Exec(ctx, del)
+	if err != nil {
+		return err
+	}
+
+	query := dialect.Update("task_relations").
+		Prepared(true).
+		Set(goqu.Record{
+			"project_id": projectId,
+			"updated":    time.Now().UnixMilli(),
+		}).
+		Where(from.In(taskIds), to.In(taskIds))
+
+	_, err = db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TaskTemplate struct {
+	RowId int `db:"rowid"`
+
+	Id        string         `db:"id"`
+	ProjectId string         `db:"project_id"`
+	BoardId   sql.NullString `db:"board_id"`
+
+	Name        string         `db:"name"`
+	Title       string         `db:"title"`
+	Description sql.NullString `db:"description"`
+
+	Tags      string `db:"tags"`
+	Checklist string `db:"checklist"`
+
+	Recurrence      sql.NullString `db:"recurrence"`
+	RecurrenceStart sql.NullInt64  `db:"recurrence_start"`
+	RecurrenceNext  sql.NullInt64  `db:"recurrence_next"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+}
+
+func TaskTemplateQuery() *goqu.SelectDataset {
+	query := dialect.From("task_templates").
+		Select(
+			"task_templates.rowid",
+
+			"task_templates.id",
+			"task_templates.project_id",
+			"task_templates.board_id",
+
+			"task_templates.name",
+			"task_templates.title",
+			"task_templates.description",
+
+			"task_templates.tags",
+			"task_templates.checklist",
+
+			"task_templates.recurrence",
+			"task_templates.recurrence_start",
+			"task_templates.recurrence_next",
+
+			"task_templates.created",
+			"task_templates.updated",
+		).
+		Prepared(true).
+		Order(goqu.I("task_templates.name").Asc())
+
+	return query
+}
+
+func (db *Database) GetTaskTemplateById(ctx context.Context, id string) (TaskTemplate, error) {
+	query := TaskTemplateQuery().
+		Where(goqu.I("task_templates.id").Eq(id))
+
+	var item TaskTemplate
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TaskTemplate{}, ErrItemNotFound
+		}
+
+		return TaskTemplate{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskTemplates(ctx context.Context, projectId string) ([]TaskTemplate, error) {
+	query := TaskTemplateQuery().
+		Where(goqu.I("task_templates.project_id").Eq(projectId))
+
+	var items []TaskTemplate
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTaskTemplateParams struct {
+	Id        string
+	ProjectId string
+	BoardId   sql.NullString
+
+	Name        string
+	Title       string
+	Description sql.NullString
+
+	Tags      string
+	Checklist string
+
+	Recurrence      sql.NullString
+	RecurrenceStart sql.NullInt64
+	RecurrenceNext  sql.NullInt64
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTaskTemplate(ctx context.Context, params CreateTaskTemplateParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateTemplateId()
+	}
+
+	query := dialect.Insert("task_templates").
+		Rows(goqu.Record{
+			"id":         id,
+			"project_id": params.ProjectId,
+			"board_id":   params.BoardId,
+
+			"name":        params.Name,
+			"title":       params.Title,
+			"description": params.Description,
+
+			"tags":      params.Tags,
+			"checklist": params.Checklist,
+
+			"recurrence":       params.Recurrence,
+			"recurrence_start": params.RecurrenceStart,
+			"recurrence_next":  params.RecurrenceNext,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("task_templates.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+type TaskTemplateChanges struct {
+	BoardId types.Change[sql.NullString]
+
+	Name        types.Change[string]
+	Title       types.Change[string]
+	Description types.Change[sql.NullString]
+
+	Tags      types.Change[string]
+	Checklist types.Change[string]
+
+	Recurrence      types.Change[sql.NullString]
+	RecurrenceStart types.Change[sql.NullInt64]
+	RecurrenceNext  types.Change[sql.NullInt64]
+}
+
+func (db *Database) UpdateTaskTemplate(ctx context.Context, id string, changes TaskTemplateChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "board_id", changes.BoardId)
+
+	addToRecord(record, "name", changes.Name)
+	addToRecord(record, "title", changes.Title)
+	addToRecord(record, "description", changes.Description)
+
+	addToRecord(record, "tags", changes.Tags)
+	addToRecord(record, "checklist", changes.Checklist)
+
+	addToRecord(record, "recurrence", changes.Recurrence)
+	addToRecord(record, "recurrence_start", changes.RecurrenceStart)
+	addToRecord(record, "recurrence_next", changes.RecurrenceNext)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("task_templates").
+		Set(record).
+		Where(goqu.I("task_templates.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetDueTaskTemplates returns the recurring templates where the next
+// occurrence is due, templates in trashed projects is skipped
+func (db *Database) GetDueTaskTemplates(ctx context.Context, now int64) ([]TaskTemplate, error) {
+	query := TaskTemplateQuery().
+		Join(
+			goqu.I("projects"),
+			goqu.On(goqu.I("task_templates.project_id").Eq(goqu.I("projects.id"))),
+		).
+		Where(
+			goqu.I("task_templates.recurrence_next").Lte(now),
+			goqu.I("projects.trash_id").IsNull(),
+		)
+
+	var items []TaskTemplate
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// AdvanceTaskTemplateRecurrence moves the next occurrence forward, only if
+// the next occurrence is still the expected one. Returns false when the
+// occurrence has already been handled.
+func (db *Database) AdvanceTaskTemplateRecurrence(ctx context.Context, id string, current int64, next sql.NullInt64) (bool, error) {
+	query := dialect.Update("task_templates").
+		Prepared(true).
+		Set(goqu.Record{
+			"recurrence_next": next,
+		}).
+		Where(
+			goqu.I("task_templates.id").Eq(id),
+			goqu.I("task_templates.recurrence_next").Eq(current),
+		)
+
+	res, err := db.Exec(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (db *Database) DeleteTaskTemplate(ctx context.Context, id string) error {
+	query := dialect.Delete("task_templates").
+		Prepared(true).
+		Where(goqu.I("task_templates.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
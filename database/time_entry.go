@@ -0,0 +1,283 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TimeEntry struct {
+	RowId int `db:"rowid"`
+
+	Id string `db:"id"`
+
+	TaskId string `db:"task_id"`
+	UserId string `db:"user_id"`
+
+	Description sql.NullString `db:"description"`
+
+	Started int64         `db:"started"`
+	Ended   sql.NullInt64 `db:"ended"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	ProjectId string `db:"project_id"`
+
+	Username        string         `db:"username"`
+	UserDisplayName sql.NullString `db:"user_display_name"`
+}
+
+// TimeEntryQuery returns the time entries for tasks that is not in the trash
+func TimeEntryQuery() *goqu.SelectDataset {
+	query := dialect.From("time_entries").
+		Select(
+			"time_entries.rowid",
+
+			"time_entries.id",
+
+			"time_entries.task_id",
+			"time_entries.user_id",
+
+			"time_entries.description",
+
+			"time_entries.started",
+			"time_entries.ended",
+
+			"time_entries.created",
+			"time_entries.updated",
+
+			goqu.I("tasks.project_id").As("project_id"),
+
+			goqu.I("users.username").As("username"),
+			goqu.I("users_settings.display_name").As("user_display_name"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("tasks"),
+			goqu.On(goqu.I("time_entries.task_id").Eq(goqu.I("tasks.id"))),
+		).
+		Join(
+			goqu.I("users"),
+			goqu.On(goqu.I("time_entries.user_id").Eq(goqu.I("users.id"))),
+		).
+		LeftJoin(
+			goqu.I("users_settings"),
+			goqu.On(goqu.I("time_entries.user_id").Eq(goqu.I("users_settings.id"))),
+		).
+		Where(goqu.I("tasks.trash_id").IsNull()).
+		Order(
+			goqu.I("time_entries.started").Desc(),
+			goqu.I("time_entries.rowid").Desc(),
+		)
+
+	return query
+}
+
+func (db *Database) GetTimeEntryById(ctx context.Context, id string) (TimeEntry, error) {
+	query := TimeEntryQuery().
+		Where(goqu.I("time_entries.id").Eq(id))
+
+	var item TimeEntry
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TimeEntry{}, ErrItemNotFound
+		}
+
+		return TimeEntry{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTaskTimeEntries(ctx context.Context, taskId string) ([]TimeEntry, error) {
+	query := TimeEntryQuery().
+		Where(goqu.I("time_entries.task_id").Eq(taskId))
+
+	var items []TimeEntry
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (db *Database) GetRunningTimeEntry(ctx context.Context, userId string) (TimeEntry, error) {
+	query := TimeEntryQuery().
+		Where(
+			goqu.I("time_entries.user_id").Eq(userId),
+			goqu.I("time_entries.ended").IsNull(),
+		)
+
+	var item TimeEntry
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TimeEntry{}, ErrItemNotFound
+		}
+
+		return TimeEntry{}, err
+	}
+
+	return item, nil
+}
+
+// GetProjectTimeEntries returns the time entries of the project that
+// overlaps the range, running entries overlaps until they are stopped
+func (db *Database) GetProjectTimeEntries(ctx context.Context, projectId string, from, to int64) ([]TimeEntry, error) {
+	query := TimeEntryQuery().
+		Where(
+			goqu.I("tasks.project_id").Eq(projectId),
+			goqu.I("time_entries.started").Lt(to),
+			goqu.Or(
+				goqu.I("time_entries.ended").IsNull(),
+				goqu.I("time_entries.ended").Gt(from),
+			),
+		)
+
+	var items []TimeEntry
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTimeEntryParams struct {
+	Id string
+
+	TaskId string
+	UserId string
+
+	Description sql.NullString
+
+	Started int64
+	Ended   sql.NullInt64
+
+	Created int64
+	Updated int64
+}
+
+func (db *Database) CreateTimeEntry(ctx context.Context, params CreateTimeEntryParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateTimeEntryId()
+	}
+
+	query := dialect.Insert("time_entries").
+		Rows(goqu.Record{
+			"id": id,
+
+			"task_id": params.TaskId,
+			"user_id": params.UserId,
+
+			"description": params.Description,
+
+			"started": params.Started,
+			"ended":   params.Ended,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("time_entries.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	return item, nil
+}
+
+type TimeEntryChanges struct {
+	Description types.Change[sql.NullString]
+
+	Started types.Change[int64]
+	Ended   types.Change[sql.NullInt64]
+}
+
+func (db *Database) UpdateTimeEntry(ctx context.Context, id string, changes TimeEntryChanges) error {
+	record := goqu.Record{}
+
+	addToRecord(record, "description", changes.Description)
+
+	addToRecord(record, "started", changes.Started)
+	addToRecord(record, "ended", changes.Ended)
+
+	if len(record) == 0 {
+		return nil
+	}
+
+	record["updated"] = time.Now().UnixMilli()
+
+	ds := dialect.Update("time_entries").
+		Set(record).
+		Where(goqu.I("time_entries.id").Eq(id)).
+		Prepared(true)
+
+	_, err := db.Exec(ctx, ds)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// StopRunningTimeEntry stops the running timer of the user, returns false if
+// no timer was running
+func (db *Database) StopRunningTimeEntry(ctx context.Context, userId string, ended int64) (bool, error) {
+	query := dialect.Update("time_entries").
+		Prepared(true).
+		Set(goqu.Record{
+			"ended":   ended,
+			"updated": time.Now().UnixMilli(),
+		}).
+		Where(
+			goqu.I("time_entries.user_id").Eq(userId),
+			goqu.I("time_entries.ended").IsNull(),
+		)
+
+	res, err := db.Exec(ctx, query)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (db *Database) DeleteTimeEntry(ctx context.Context, id string) error {
+	query := dialect.Delete("time_entries").
+		Prepared(true).
+		Where(goqu.I("time_entries.id").Eq(id))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,388 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+type TrashItem struct {
+	RowId int `db:"rowid"`
+
+	Id        string `db:"id"`
+	OwnerId   string `db:"owner_id"`
+	ProjectId string `db:"project_id"`
+
+	Type   types.TrashType `db:"type"`
+	ItemId string          `db:"item_id"`
+	Name   string          `db:"name"`
+
+	Created int64 `db:"created"`
+	Updated int64 `db:"updated"`
+
+	ProjectName string `db:"project_name"`
+}
+
+func TrashQuery() *goqu.SelectDataset {
+	query := dialect.From("trash").
+		Select(
+			"trash.rowid",
+
+			"trash.id",
+			"trash.owner_id",
+			"trash.project_id",
+
+			"trash.type",
+			"trash.item_id",
+			"trash.name",
+
+			"trash.created",
+			"trash.updated",
+
+			goqu.I("projects.name").As("project_name"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("projects"),
+			goqu.On(goqu.I("trash.project_id").Eq(goqu.I("projects.id"))),
+		).
+		Order(goqu.I("trash.created").Desc())
+
+	return query
+}
+
+func (db *Database) GetTrashItemById(ctx context.Context, id string) (TrashItem, error) {
+	query := TrashQuery().
+		Where(goqu.I("trash.id").Eq(id))
+
+	var item TrashItem
+	err := db.Get(&item, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TrashItem{}, ErrItemNotFound
+		}
+
+		return TrashItem{}, err
+	}
+
+	return item, nil
+}
+
+func (db *Database) GetTrashItemsByOwner(ctx context.Context, ownerId string) ([]TrashItem, error) {
+	query := TrashQuery().
+		Where(goqu.I("trash.owner_id").Eq(ownerId))
+
+	var items []TrashItem
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetExpiredTrashItems returns the items that was trashed before the time
+func (db *Database) GetExpiredTrashItems(ctx context.Context, before int64) ([]TrashItem, error) {
+	query := TrashQuery().
+		Where(goqu.I("trash.created").Lt(before))
+
+	var items []TrashItem
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+type CreateTrashItemParams struct {
+	Id        string
+	OwnerId   string
+	ProjectId string
+
+	Type   types.TrashType
+	ItemId string
+	Name   string
+
+	Created int64
+	Updated int64
+}
+
+// CreateTrashItem creates the trash entry and moves the item together with
+// every item below it that isn't already in the trash into the trash. Should
+// be called inside a transaction.
+func (db *Database) CreateTrashItem(ctx context.Context, params CreateTrashItemParams) (string, error) {
+	t := time.Now().UnixMilli()
+	created := params.Created
+	updated := params.Updated
+
+	if created == 0 && updated == 0 {
+		created = t
+		updated = t
+	}
+
+	id := params.Id
+	if id == "" {
+		id = utils.CreateTrashId()
+	}
+
+	query := dialect.Insert("trash").
+		Rows(goqu.Record{
+			"id":         id,
+			"owner_id":   params.OwnerId,
+			"project_id": params.ProjectId,
+
+			"type":    params.Type,
+			"item_id": params.ItemId,
+			"name":    params.Name,
+
+			"created": created,
+			"updated": updated,
+		}).
+		Returning("trash.id").
+		Prepared(true)
+
+	var item string
+	err := db.Get(&item, query)
+	if err != nil {
+		return "", err
+	}
+
+	var queries []ToSQL
+	setTrash := goqu.Record{"trash_id": item}
+
+	switch params.Type {
+	case types.TrashTypeProject:
+		queries = append(queries,
+			dialect.Update("projects").Prepared(true).Set(setTrash).
+				Where(goqu.I("projects.id").Eq(params.ItemId)),
+			dialect.Update("boards").Prepared(true).Set(setTrash).
+				Where(
+					goqu.I("boards.project_id").Eq(params.ItemId),
+					goqu.I("boards.trash_id").IsNull(),
+				),
+			dialect.Update("tasks").Prepared(true).Set(setTrash).
+				Where(
+					goqu.I("tasks.project_id").Eq(params.ItemId),
+					goqu.I("tasks.trash_id").IsNull(),
+				),
+		)
+	case types.TrashTypeBoard:
+		queries = append(queries,
+			dialect.Update("boards").Prepared(true).Set(setTrash).
+				Where(goqu.I("boards.id").Eq(params.ItemId)),
+			dialect.Update("tasks").Prepared(true).Set(setTrash).
+				Where(
+					goqu.I("tasks.board_id").Eq(params.ItemId),
+					goqu.I("tasks.trash_id").IsNull(),
+				),
+		)
+	case types.TrashTypeTask:
+		// NOTE(patrik): The subtasks is trashed together with the task so
+		// they are restored and purged together
+		subtree := goqu.L(
+			"WITH RECURSIVE subtree(id) AS (SELECT ? UNION SELECT tasks.id FROM tasks JOIN subtree ON tasks.parent_id = subtree.id) SELECT id FROM subtree",
+			params.ItemId,
+		)
+
+		queries = append(queries,
+			dialect.Update("tasks").Prepared(true).Set(setTrash).
+				Where(
+					goqu.I("tasks.id").In(subtree),
+					goqu.I("tasks.trash_id").IsNull(),
+				),
+		)
+	case types.TrashTypeAttachment:
+		queries = append(queries,
+			dialect.Update("attachments").Prepared(true).Set(setTrash).
+				Where(goqu.I("attachments.id").Eq(params.ItemId)),
+		)
+	}
+
+	for _, query := range queries {
+		_, err := db.Exec(ctx, query)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return item, nil
+}
+
+// IsTrashItemParentTrashed checks if the board or project the item belongs
+// to is in the trash, the item can't be restored before them
+func (db *Database) IsTrashItemParentTrashed(ctx context.Context, item TrashItem) (bool, error) {
+	var query *goqu.SelectDataset
+
+	switch item.Type {
+	case types.TrashTypeBoard:
+		query = dialect.From("boards").
+			Select(goqu.COUNT("*")).
+			Join(
+				goqu.I("projects"),
+				goqu.On(goqu.I("boards.project_id").Eq(goqu.I("projects.id"))),
+			).
+			Where(
+				goqu.I("boards.id").Eq(item.ItemId),
+				goqu.I("projects.trash_id").IsNotNull(),
+			)
+	case types.TrashTypeTask:
+		query = dialect.From("tasks").
+			Select(goqu.COUNT("*")).
+			Join(
+				goqu.I("boards"),
+				goqu.On(goqu.I("tasks.board_id").Eq(goqu.I("boards.id"))),
+			).
+			Join(
+				goqu.I("projects"),
+				goqu.On(goqu.I("tasks.project_id").Eq(goqu.I("projects.id"))),
+			).
+			Where(
+				goqu.I("tasks.id").Eq(item.ItemId),
+				goqu.Or(
+					goqu.I("boards.trash_id").IsNotNull(),
+					goqu.I("projects.trash_id").IsNotNull(),
+				),
+			)
+	case types.TrashTypeAttachment:
+		// NOTE(patrik): Trashing a board or project trashes the tasks as
+		// well so only the task needs to be checked
+		query = dialect.From("attachments").
+			Select(goqu.COUNT("*")).
+			Join(
+				goqu.I("tasks"),
+				goqu.On(goqu.I("attachments.task_id").Eq(goqu.I("tasks.id"))),
+			).
+			Where(
+				goqu.I("attachments.id").Eq(item.ItemId),
+				goqu.I("tasks.trash_id").IsNotNull(),
+			)
+	default:
+		return false, nil
+	}
+
+	var count int64
+	err := db.Get(&count, query.Prepared(true))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// RestoreTrashItem moves everything that was trashed together back out of
+// the trash and removes the entry. Should be called inside a transaction.
+func (db *Database) RestoreTrashItem(ctx context.Context, id string) error {
+	clearTrash := goqu.Record{"trash_id": nil}
+
+	queries := []ToSQL{
+		dialect.Update("projects").Prepared(true).Set(clearTrash).
+			Where(goqu.I("projects.trash_id").Eq(id)),
+		dialect.Update("boards").Prepared(true).Set(clearTrash).
+			Where(goqu.I("boards.trash_id").Eq(id)),
+		dialect.Update("tasks").Prepared(true).Set(clearTrash).
+			Where(goqu.I("tasks.trash_id").Eq(id)),
+		dialect.Update("attachments").Prepared(true).Set(clearTrash).
+			Where(goqu.I("attachments.trash_id").Eq(id)),
+		dialect.Delete("trash").Prepared(true).
+			Where(goqu.I("trash.id").Eq(id)),
+	}
+
+	for _, query := range queries {
+		_, err := db.Exec(ctx, query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeTrashItem permanently deletes the trashed item, the database
+// cascades the delete to everything below it. Returns the ids of every
+// trash entry that was removed, entries for items that was inside the
+// purged item is removed as well. Should be called inside a transaction.
+func (db *Database) PurgeTrashItem(ctx context.Context, item TrashItem) ([]string, error) {
+	var removed []string
+
+	switch item.Type {
+	case types.TrashTypeProject:
+		// NOTE(patrik): Removing the project cascades to every entry of the
+		// project so the ids needs to be collected before
+		err := db.Select(&removed, dialect.From("trash").
+			Prepared(true).
+			Select("trash.id").
+			Where(goqu.I("trash.project_id").Eq(item.ItemId)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = db.Exec(ctx, dialect.Delete("projects").
+			Prepared(true).
+			Where(goqu.I("projects.id").Eq(item.ItemId)),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return removed, nil
+	case types.TrashTypeBoard:
+		_, err := db.Exec(ctx, dialect.Delete("boards").
+			Prepared(true).
+			Where(goqu.I("boards.id").Eq(item.ItemId)),
+		)
+		if err != nil {
+			return nil, err
+		}
+	case types.TrashTypeTask:
+		// NOTE(patrik): The subtasks is only set to null when the parent is
+		// deleted so the subtasks trashed with the task is deleted here
+		_, err := db.Exec(ctx, dialect.Delete("tasks").
+			Prepared(true).
+			Where(goqu.Or(
+				goqu.I("tasks.id").Eq(item.ItemId),
+				goqu.I("tasks.trash_id").Eq(item.Id),
+			)),
+		)
+		if err != nil {
+			return nil, err
+		}
+	case types.TrashTypeAttachment:
+		_, err := db.Exec(ctx, dialect.Delete("attachments").
+			Prepared(true).
+			Where(goqu.I("attachments.id").Eq(item.ItemId)),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	missing := func(trashType types.TrashType, table string) goqu.Expression {
+		return goqu.And(
+			goqu.I("trash.type").Eq(trashType),
+			goqu.I("trash.item_id").NotIn(dialect.From(table).Select("id")),
+		)
+	}
+
+	err := db.Select(&removed, dialect.Delete("trash").
+		Prepared(true).
+		Where(goqu.Or(
+			goqu.I("trash.id").Eq(item.Id),
+			missing(types.TrashTypeBoard, "boards"),
+			missing(types.TrashTypeTask, "tasks"),
+			missing(types.TrashTypeAttachment, "attachments"),
+		)).
+		Returning("trash.id"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return removed, nil
+}
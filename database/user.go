@@ -14,6 +14,7 @@ import (
 type UserSettings struct {
 	Id            string         `db:"id"`
 	DisplayName   sql.NullString `db:"display_name"`
+	Timezone      sql.NullString `db:"timezone"`
 }
 
 type User struct {
@@ -27,12 +28,14 @@ type User struct {
 
 	// NOTE(patrik): This needs to match UserSettings
 	DisplayName   sql.NullString `db:"display_name"`
+	Timezone      sql.NullString `db:"timezone"`
 }
 
 func (u User) ToUserSettings() UserSettings {
 	return UserSettings{
 		Id:            u.Id,
 		DisplayName:   u.DisplayName,
+		Timezone:      u.Timezone,
 	}
 }
 
@@ -49,6 +52,7 @@ func UserQuery() *goqu.SelectDataset {
 			"users.updated",
 
 			"users_settings.display_name",
+			"users_settings.timezone",
 		).
 		Prepared(true).
 		LeftJoin(
@@ -64,6 +68,7 @@ func UserSettingsQuery() *goqu.SelectDataset {
 		Select(
 			"users_settings.id",
 			"users_settings.display_name",
+			"users_settings.timezone",
 		).
 		Prepared(true)
 
@@ -227,10 +232,12 @@ func (db *Database) UpdateUserSettings(ctx context.Context, settings UserSetting
 		Rows(goqu.Record{
 			"id":             settings.Id,
 			"display_name":   settings.DisplayName,
+			"timezone":       settings.Timezone,
 		}).
 		Prepared(true).
 		OnConflict(goqu.DoUpdate("id", goqu.Record{
 			"display_name":   settings.DisplayName,
+			"timezone":       settings.Timezone,
 		}))
 
 	_, err := db.Exec(ctx, query)
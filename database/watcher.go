@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+const (
+	WatchViaTask  = "task"
+	WatchViaBoard = "board"
+)
+
+type TaskWatcher struct {
+	UserId string `db:"user_id"`
+
+	// NOTE(patrik): How the user is subscribed to the task, WatchViaTask or
+	// WatchViaBoard
+	Via string `db:"via"`
+
+	Created int64 `db:"created"`
+
+	Username        string         `db:"username"`
+	UserDisplayName sql.NullString `db:"user_display_name"`
+}
+
+type TaskSubscription struct {
+	TaskId string `db:"task_id"`
+
+	Created int64 `db:"created"`
+
+	TaskTitle  string `db:"task_title"`
+	TaskNumber int64  `db:"task_number"`
+	ProjectId  string `db:"project_id"`
+	ProjectKey string `db:"project_key"`
+}
+
+type BoardSubscription struct {
+	BoardId string `db:"board_id"`
+
+	Created int64 `db:"created"`
+
+	BoardName string `db:"board_name"`
+	ProjectId string `db:"project_id"`
+}
+
+func watcherQuery(table, via string) *goqu.SelectDataset {
+	query := dialect.From(table).
+		Select(
+			goqu.I(table+".user_id").As("user_id"),
+			goqu.V(via).As("via"),
+			goqu.I(table+".created").As("created"),
+
+			goqu.I("users.username").As("username"),
+			goqu.I("users_settings.display_name").As("user_display_name"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("users"),
+			goqu.On(goqu.I(table+".user_id").Eq(goqu.I("users.id"))),
+		).
+		LeftJoin(
+			goqu.I("users_settings"),
+			goqu.On(goqu.I(table+".user_id").Eq(goqu.I("users_settings.id"))),
+		)
+
+	return query
+}
+
+// GetTaskWatchers resolves every user subscribed to the task, either by
+// watching the task or by watching the board the task is on. Users that
+// has unwatched the task is left out even if they watch the board. This is
+// the list changes to the task should be fanned out to.
+func (db *Database) GetTaskWatchers(ctx context.Context, task Task) ([]TaskWatcher, error) {
+	taskQuery := watcherQuery("task_watchers", WatchViaTask).
+		Where(
+			goqu.I("task_watchers.task_id").Eq(task.Id),
+			goqu.I("task_watchers.watching").IsTrue(),
+		)
+
+	var items []TaskWatcher
+	err := db.Select(&items, taskQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	decided := dialect.From("task_watchers").
+		Select("task_watchers.user_id").
+		Where(goqu.I("task_watchers.task_id").Eq(task.Id))
+
+	boardQuery := watcherQuery("board_watchers", WatchViaBoard).
+		Where(
+			goqu.I("board_watchers.board_id").Eq(task.BoardId),
+			goqu.I("board_watchers.user_id").NotIn(decided),
+		)
+
+	var boardItems []TaskWatcher
+	err = db.Select(&boardItems, boardQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	items = append(items, boardItems...)
+	slices.SortStableFunc(items, func(a, b TaskWatcher) int {
+		return int(a.Created - b.Created)
+	})
+
+	return items, nil
+}
+
+// AddTaskWatcher subscribes the user to the task unless the user has
+// unwatched the task before, used for the automatic subscriptions
+func (db *Database) AddTaskWatcher(ctx context.Context, taskId, userId string) error {
+	t := time.Now().UnixMilli()
+
+	query := dialect.Insert("task_watchers").
+		Prepared(true).
+		Rows(goqu.Record{
+			"task_id":  taskId,
+			"user_id":  userId,
+			"watching": true,
+			"created":  t,
+			"updated":  t,
+		}).
+		OnConflict(goqu.DoNothing())
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) SetTaskWatching(ctx context.Context, taskId, userId string, watching bool) error {
+	t := time.Now().UnixMilli()
+
+	query := dialect.Insert("task_watchers").
+		Prepared(true).
+		Rows(goqu.Record{
+			"task_id":  taskId,
+			"user_id":  userId,
+			"watching": watching,
+			"created":  t,
+			"updated":  t,
+		}).
+		OnConflict(goqu.DoUpdate("task_id, user_id", goqu.Record{
+			"watching": goqu.I("excluded.watching"),
+			"updated":  goqu.I("excluded.updated"),
+		}))
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) AddBoardWatcher(ctx context.Context, boardId, userId string) error {
+	query := dialect.Insert("board_watchers").
+		Prepared(true).
+		Rows(goqu.Record{
+			"board_id": boardId,
+			"user_id":  userId,
+			"created":  time.Now().UnixMilli(),
+		}).
+		OnConflict(goqu.DoNothing())
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (db *Database) RemoveBoardWatcher(ctx context.Context, boardId, userId string) error {
+	query := dialect.Delete("board_watchers").
+		Prepared(true).
+		Where(
+			goqu.I("board_watchers.board_id").Eq(boardId),
+			goqu.I("board_watchers.user_id").Eq(userId),
+		)
+
+	_, err := db.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetUserTaskSubscriptions returns the tasks the user watches directly,
+// trashed tasks is not included
+func (db *Database) GetUserTaskSubscriptions(ctx context.Context, userId string) ([]TaskSubscription, error) {
+	query := dialect.From("task_watchers").
+		Select(
+			"task_watchers.task_id",
+
+			"task_watchers.created",
+
+			goqu.I("tasks.title").As("task_title"),
+			goqu.I("tasks.number").As("task_number"),
+			goqu.I("tasks.project_id").As("project_id"),
+			goqu.I("projects.key").As("project_key"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("tasks"),
+			goqu.On(goqu.I("task_watchers.task_id").Eq(goqu.I("tasks.id"))),
+		).
+		Join(
+			goqu.I("projects"),
+			goqu.On(goqu.I("tasks.project_id").Eq(goqu.I("projects.id"))),
+		).
+		Where(
+			goqu.I("task_watchers.user_id").Eq(userId),
+			goqu.I("task_watchers.watching").IsTrue(),
+			goqu.I("tasks.trash_id").IsNull(),
+		).
+		Order(goqu.I("task_watchers.created").Desc())
+
+	var items []TaskSubscription
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetUserBoardSubscriptions returns the boards the user watches, trashed
+// boards is not included
+func (db *Database) GetUserBoardSubscriptions(ctx context.Context, userId string) ([]BoardSubscription, error) {
+	query := dialect.From("board_watchers").
+		Select(
+			"board_watchers.board_id",
+
+			"board_watchers.created",
+
+			goqu.I("boards.name").As("board_name"),
+			goqu.I("boards.project_id").As("project_id"),
+		).
+		Prepared(true).
+		Join(
+			goqu.I("boards"),
+			goqu.On(goqu.I("board_watchers.board_id").Eq(goqu.I("boards.id"))),
+		).
+		Where(
+			goqu.I("board_watchers.user_id").Eq(userId),
+			goqu.I("boards.trash_id").IsNull(),
+		).
+		Order(goqu.I("board_watchers.created").Desc())
+
+	var items []BoardSubscription
+	err := db.Select(&items, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,142 @@
+// Package burndown calculates the burndown chart of a sprint from the
+// history of the tasks that has been part of the sprint.
+//
+// The chart is based on the state of every task at the end of each day, so
+// tasks that is added, removed or completed during the sprint is counted
+// on the day it happened. When the sprint is closed the chart stops at the
+// time of the close, the unfinished tasks that is moved out of the sprint
+// by the close is still counted as remaining.
+package burndown
+
+import (
+	"time"
+)
+
+const DateLayout = "2006-01-02"
+
+// State is the state of a task after an event
+type State struct {
+	InSprint bool
+	Done     bool
+	Estimate int64
+}
+
+type Event struct {
+	// NOTE(patrik): Unix milliseconds, the state is in effect from this
+	// time
+	Time  int64
+	State State
+}
+
+// Task is the events of a single task sorted with the oldest first, the
+// first event is the state the task had before the known history
+type Task struct {
+	Events []Event
+}
+
+func (t Task) stateAt(at int64) State {
+	var state State
+	for _, event := range t.Events {
+		if event.Time >= at {
+			break
+		}
+
+		state = event.State
+	}
+
+	return state
+}
+
+type Day struct {
+	Date string
+
+	// NOTE(patrik): The points left if the sprint is completed at an even
+	// pace
+	Ideal float64
+
+	// NOTE(patrik): Nil for the days that hasn't happened yet
+	Remaining *int64
+	Completed *int64
+}
+
+type Result struct {
+	// NOTE(patrik): The scope of the sprint at the end, or at the close
+	Points int64
+	Days   []Day
+}
+
+type Sprint struct {
+	// NOTE(patrik): Midnight UTC, both dates is inclusive
+	Start time.Time
+	End   time.Time
+
+	// NOTE(patrik): Zero when the sprint is still open
+	Closed int64
+}
+
+func points(tasks []Task, at int64) (remaining, completed int64) {
+	for _, task := range tasks {
+		state := task.stateAt(at)
+		if !state.InSprint {
+			continue
+		}
+
+		if state.Done {
+			completed += state.Estimate
+		} else {
+			remaining += state.Estimate
+		}
+	}
+
+	return remaining, completed
+}
+
+// Build calculates the burndown for every day of the sprint, the days is
+// the calendar dates of the sprint and a day ends at midnight in loc
+func Build(sprint Sprint, tasks []Task, loc *time.Location, now time.Time) Result {
+	cutoff := now.UnixMilli()
+	if sprint.Closed != 0 && sprint.Closed < cutoff {
+		cutoff = sprint.Closed
+	}
+
+	remaining, completed := points(tasks, cutoff)
+	total := remaining + completed
+
+	res := Result{
+		Points: total,
+		Days:   []Day{},
+	}
+
+	start := sprint.Start.UTC()
+	count := int(sprint.End.UTC().Sub(start).Hours()/24) + 1
+
+	for i := 0; i < count; i++ {
+		day := start.AddDate(0, 0, i)
+
+		ideal := float64(total)
+		if count > 1 {
+			ideal = float64(total) * float64(count-1-i) / float64(count-1)
+		}
+
+		item := Day{
+			Date:  day.Format(DateLayout),
+			Ideal: ideal,
+		}
+
+		dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc).UnixMilli()
+		if dayStart <= cutoff {
+			end := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc).UnixMilli()
+			if end > cutoff {
+				end = cutoff
+			}
+
+			r, c := points(tasks, end)
+			item.Remaining = &r
+			item.Completed = &c
+		}
+
+		res.Days = append(res.Days, item)
+	}
+
+	return res
+}
@@ -0,0 +1,140 @@
+package burndown
+
+import (
+	"testing"
+	"time"
+)
+
+func at(day, hour int) int64 {
+	return time.Date(2026, 10, day, hour, 0, 0, 0, time.UTC).UnixMilli()
+}
+
+func check(t *testing.T, res Result, remaining, completed []int64) {
+	t.Helper()
+
+	if len(res.Days) != len(remaining) {
+		t.Fatalf("len(Days) = %d, want %d", len(res.Days), len(remaining))
+	}
+
+	for i, day := range res.Days {
+		if remaining[i] < 0 {
+			if day.Remaining != nil || day.Completed != nil {
+				t.Errorf("%s: expected no values", day.Date)
+			}
+			continue
+		}
+
+		if day.Remaining == nil || day.Completed == nil {
+			t.Errorf("%s: missing values", day.Date)
+			continue
+		}
+
+		if *day.Remaining != remaining[i] || *day.Completed != completed[i] {
+			t.Errorf("%s: remaining = %d, completed = %d, want %d, %d", day.Date, *day.Remaining, *day.Completed, remaining[i], completed[i])
+		}
+	}
+}
+
+var sprint = Sprint{
+	Start: time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC),
+	End:   time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+}
+
+func TestBuildClosedWithUnfinished(t *testing.T) {
+	closed := at(15, 12)
+
+	tasks := []Task{
+		// NOTE(patrik): Completed on the 13th
+		{Events: []Event{
+			{Time: at(10, 9), State: State{InSprint: true, Estimate: 3}},
+			{Time: at(13, 9), State: State{InSprint: true, Done: true, Estimate: 3}},
+		}},
+		// NOTE(patrik): Unfinished, moved to the next sprint by the close
+		{Events: []Event{
+			{Time: at(10, 9), State: State{InSprint: true, Estimate: 5}},
+			{Time: closed, State: State{InSprint: false, Estimate: 5}},
+		}},
+		// NOTE(patrik): Added during the sprint, unfinished
+		{Events: []Event{
+			{Time: at(14, 9), State: State{InSprint: true, Estimate: 2}},
+			{Time: closed, State: State{InSprint: false, Estimate: 2}},
+		}},
+	}
+
+	s := sprint
+	s.Closed = closed
+
+	res := Build(s, tasks, time.UTC, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC))
+
+	if res.Points != 10 {
+		t.Errorf("Points = %d, want 10", res.Points)
+	}
+
+	check(t, res,
+		[]int64{8, 5, 7, 7, -1},
+		[]int64{0, 3, 3, 3, 0},
+	)
+}
+
+func TestBuildOpen(t *testing.T) {
+	tasks := []Task{
+		// NOTE(patrik): Completed before the sprint started
+		{Events: []Event{
+			{Time: at(1, 0), State: State{InSprint: true, Done: true, Estimate: 1}},
+		}},
+		// NOTE(patrik): Completed then reopened
+		{Events: []Event{
+			{Time: at(1, 0), State: State{InSprint: true, Estimate: 4}},
+			{Time: at(12, 10), State: State{InSprint: true, Done: true, Estimate: 4}},
+			{Time: at(13, 10), State: State{InSprint: true, Estimate: 4}},
+		}},
+		// NOTE(patrik): Never part of the sprint
+		{Events: []Event{
+			{Time: at(1, 0), State: State{Estimate: 8}},
+		}},
+	}
+
+	res := Build(sprint, tasks, time.UTC, time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC))
+
+	if res.Points != 5 {
+		t.Errorf("Points = %d, want 5", res.Points)
+	}
+
+	check(t, res,
+		[]int64{0, 4, 4, -1, -1},
+		[]int64{5, 1, 1, 0, 0},
+	)
+
+	if res.Days[0].Ideal != 5 || res.Days[4].Ideal != 0 {
+		t.Errorf("Ideal = %v .. %v", res.Days[0].Ideal, res.Days[4].Ideal)
+	}
+}
+
+func TestBuildTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone not available: %v", err)
+	}
+
+	// NOTE(patrik): 02:00 UTC on the 13th is still the 12th in New York
+	tasks := []Task{
+		{Events: []Event{
+			{Time: at(1, 0), State: State{InSprint: true, Estimate: 2}},
+			{Time: at(13, 2), State: State{InSprint: true, Done: true, Estimate: 2}},
+		}},
+	}
+
+	res := Build(sprint, tasks, loc, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC))
+
+	check(t, res,
+		[]int64{0, 0, 0, 0, 0},
+		[]int64{2, 2, 2, 2, 2},
+	)
+
+	res = Build(sprint, tasks, time.UTC, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC))
+
+	check(t, res,
+		[]int64{2, 0, 0, 0, 0},
+		[]int64{0, 2, 2, 2, 2},
+	)
+}
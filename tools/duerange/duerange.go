@@ -0,0 +1,61 @@
+// Package duerange builds the bounds used when filtering tasks by due date.
+//
+// Due dates without time is stored as midnight UTC so the calendar date is
+// the same in every timezone, while due dates with time is a point in time.
+// A bound therefore has one value for each kind of due date, otherwise the
+// bound is shifted by the offset of the users timezone for one of them.
+package duerange
+
+import (
+	"time"
+
+	"github.com/nanoteck137/beldum/tools/utils"
+)
+
+type Bound struct {
+	// NOTE(patrik): Compared against due dates without time, midnight UTC
+	Date int64
+	// NOTE(patrik): Compared against due dates with time
+	Time int64
+}
+
+// Parse parses the bound in the same formats as utils.ParseDue. A date
+// without time is the start of the date in loc for the tasks with time. A
+// time is rounded up to the next date for the tasks without time, a task
+// without time is due at the start of the date.
+func Parse(s string, loc *time.Location) (Bound, error) {
+	t, hasTime, err := utils.ParseDue(s, loc)
+	if err != nil {
+		return Bound{}, err
+	}
+
+	if !hasTime {
+		local := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+		return Bound{
+			Date: t.UnixMilli(),
+			Time: local.UnixMilli(),
+		}, nil
+	}
+
+	local := t.In(loc)
+	date := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if local.After(start) {
+		date = date.AddDate(0, 0, 1)
+	}
+
+	return Bound{
+		Date: date.UnixMilli(),
+		Time: t.UnixMilli(),
+	}, nil
+}
+
+// Value returns the value a due date should be compared against
+func (b Bound) Value(hasTime bool) int64 {
+	if hasTime {
+		return b.Time
+	}
+
+	return b.Date
+}
@@ -0,0 +1,105 @@
+package duerange
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %q not available: %v", name, err)
+	}
+
+	return loc
+}
+
+// inRange mirrors the filter in database.TaskQuery, from is inclusive and
+// to is exclusive
+func inRange(due int64, hasTime bool, from, to Bound) bool {
+	return due >= from.Value(hasTime) && due < to.Value(hasTime)
+}
+
+func TestParseDate(t *testing.T) {
+	for _, name := range []string{"America/New_York", "Asia/Tokyo", "UTC"} {
+		loc := mustLoad(t, name)
+
+		from, err := Parse("2026-10-18", loc)
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", name, err)
+		}
+
+		to, err := Parse("2026-10-19", loc)
+		if err != nil {
+			t.Fatalf("%s: Parse failed: %v", name, err)
+		}
+
+		// NOTE(patrik): Dates without time is stored as midnight UTC
+		date := func(day int) int64 {
+			return time.Date(2026, 10, day, 0, 0, 0, 0, time.UTC).UnixMilli()
+		}
+
+		local := func(day, hour, min int) int64 {
+			return time.Date(2026, 10, day, hour, min, 0, 0, loc).UnixMilli()
+		}
+
+		tests := []struct {
+			due     int64
+			hasTime bool
+			want    bool
+		}{
+			{date(17), false, false},
+			{date(18), false, true},
+			{date(19), false, false},
+			{local(17, 23, 59), true, false},
+			{local(18, 0, 0), true, true},
+			{local(18, 23, 59), true, true},
+			{local(19, 0, 0), true, false},
+		}
+
+		for _, test := range tests {
+			got := inRange(test.due, test.hasTime, from, to)
+			if got != test.want {
+				t.Errorf("%s: inRange(%v, %v) = %v, want %v", name, time.UnixMilli(test.due).In(loc), test.hasTime, got, test.want)
+			}
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := mustLoad(t, "America/New_York")
+
+	b, err := Parse("2026-10-18T09:30", loc)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want := time.Date(2026, 10, 18, 9, 30, 0, 0, loc).UnixMilli()
+	if b.Time != want {
+		t.Errorf("Time = %v, want %v", time.UnixMilli(b.Time), time.UnixMilli(want))
+	}
+
+	// NOTE(patrik): Tasks without time due on the 18th starts before the
+	// bound so the date is rounded up
+	want = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC).UnixMilli()
+	if b.Date != want {
+		t.Errorf("Date = %v, want %v", time.UnixMilli(b.Date).UTC(), time.UnixMilli(want).UTC())
+	}
+
+	b, err = Parse("2026-10-18T00:00", loc)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	want = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC).UnixMilli()
+	if b.Date != want {
+		t.Errorf("Date = %v, want %v", time.UnixMilli(b.Date).UTC(), time.UnixMilli(want).UTC())
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse("18/10/2026", time.UTC)
+	if err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,28 @@
+package markdown
+
+import (
+	"bytes"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var renderer = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// NOTE(patrik): UGCPolicy strips scripts, event handlers and other
+// dangerous attributes while keeping the normal markdown output
+var policy = bluemonday.UGCPolicy()
+
+// Render converts the markdown source to sanitized html
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	err := renderer.Convert([]byte(source), &buf)
+	if err != nil {
+		return "", err
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
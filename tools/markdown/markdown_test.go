@@ -0,0 +1,54 @@
+package markdown_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nanoteck137/beldum/tools/markdown"
+)
+
+func TestRender(t *testing.T) {
+	type test struct {
+		source   string
+		contains []string
+		excludes []string
+	}
+
+	tests := []test{
+		{
+			source:   "# Hello World",
+			contains: []string{"<h1>Hello World</h1>"},
+		},
+		{
+			source:   "<script>alert(1)</script>",
+			excludes: []string{"<script", "alert(1)"},
+		},
+		{
+			source:   "[link](javascript:alert(1))",
+			excludes: []string{"javascript:"},
+		},
+		{
+			source:   "<a href=\"/test\" onclick=\"alert(1)\">test</a>",
+			excludes: []string{"onclick"},
+		},
+	}
+
+	for i, test := range tests {
+		html, err := markdown.Render(test.source)
+		if err != nil {
+			t.Fatalf("Test %d Failed: %v", i, err)
+		}
+
+		for _, s := range test.contains {
+			if !strings.Contains(html, s) {
+				t.Errorf("Test %d Failed: (%q) Expected %q in %q", i, test.source, s, html)
+			}
+		}
+
+		for _, s := range test.excludes {
+			if strings.Contains(html, s) {
+				t.Errorf("Test %d Failed: (%q) Unexpected %q in %q", i, test.source, s, html)
+			}
+		}
+	}
+}
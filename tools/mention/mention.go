@@ -0,0 +1,106 @@
+// Package mention finds the tasks mentioned inside markdown text, a task is
+// mentioned either by the key (ABC-12) or by the id. Mentions inside code
+// spans, code blocks and raw html is ignored.
+package mention
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/text"
+)
+
+var md = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+)
+
+// NOTE(patrik): Keys needs to be written in uppercase so words like
+// "covid-19" isn't treated as a key
+var keyRegex = regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,9}-[1-9][0-9]{0,9}\b`)
+
+// NOTE(patrik): Matches the format of utils.CreateTaskId
+var idRegex = regexp.MustCompile(`\b[a-z][a-z0-9]{15}\b`)
+
+type Mentions struct {
+	Keys []string
+	Ids  []string
+}
+
+func (m Mentions) Empty() bool {
+	return len(m.Keys) == 0 && len(m.Ids) == 0
+}
+
+func skipNode(node ast.Node) bool {
+	switch node.Kind() {
+	case ast.KindCodeSpan, ast.KindCodeBlock, ast.KindFencedCodeBlock,
+		ast.KindHTMLBlock, ast.KindRawHTML:
+		return true
+	}
+
+	return false
+}
+
+// plainText returns the text of the markdown without the code and html
+func plainText(source string) string {
+	src := []byte(source)
+	doc := md.Parser().Parse(text.NewReader(src))
+
+	var b strings.Builder
+	_ = ast.Walk(doc, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		if skipNode(node) {
+			b.WriteByte(' ')
+			return ast.WalkSkipChildren, nil
+		}
+
+		if !entering {
+			if node.Type() == ast.TypeBlock {
+				b.WriteByte('\n')
+			}
+
+			return ast.WalkContinue, nil
+		}
+
+		if n, ok := node.(*ast.Text); ok {
+			b.Write(n.Segment.Value(src))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte('\n')
+			}
+		}
+
+		return ast.WalkContinue, nil
+	})
+
+	return b.String()
+}
+
+func unique(arr []string) []string {
+	res := []string{}
+	for _, s := range arr {
+		if !slices.Contains(res, s) {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
+
+// Extract returns the keys and the ids mentioned in the markdown, every
+// mention is only included once
+func Extract(sources ...string) Mentions {
+	var keys, ids []string
+
+	for _, source := range sources {
+		s := plainText(source)
+		keys = append(keys, keyRegex.FindAllString(s, -1)...)
+		ids = append(ids, idRegex.FindAllString(s, -1)...)
+	}
+
+	return Mentions{
+		Keys: unique(keys),
+		Ids:  unique(ids),
+	}
+}
@@ -0,0 +1,51 @@
+package mention
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		sources []string
+		keys    []string
+		ids     []string
+	}{
+		{
+			sources: []string{"See ABC-12 and **DEF-3**, also ABC-12 again"},
+			keys:    []string{"ABC-12", "DEF-3"},
+		},
+		{
+			sources: []string{"Fix covid-19 page, not ABC-0 or A-1"},
+		},
+		{
+			sources: []string{"Same as ck4p2x9q0a1b2c3d", "title (ABC-1)"},
+			keys:    []string{"ABC-1"},
+			ids:     []string{"ck4p2x9q0a1b2c3d"},
+		},
+		{
+			sources: []string{"Use `ABC-1` here\n\n```\nABC-2\n```\n\n    ABC-3\n\n<div>ABC-4</div>\n\nbut ABC-5"},
+			keys:    []string{"ABC-5"},
+		},
+		{
+			sources: []string{"- item ABC-6\n- [link ABC-7](http://example.com)\n> quote ABC-8"},
+			keys:    []string{"ABC-6", "ABC-7", "ABC-8"},
+		},
+		{
+			sources: []string{"line ABC-9\nABC-10 next"},
+			keys:    []string{"ABC-9", "ABC-10"},
+		},
+	}
+
+	for i, test := range tests {
+		res := Extract(test.sources...)
+
+		if !slices.Equal(res.Keys, test.keys) {
+			t.Errorf("Test %d: Keys = %v, want %v", i, res.Keys, test.keys)
+		}
+
+		if !slices.Equal(res.Ids, test.ids) {
+			t.Errorf("Test %d: Ids = %v, want %v", i, res.Ids, test.ids)
+		}
+	}
+}
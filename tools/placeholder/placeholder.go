@@ -0,0 +1,40 @@
+package placeholder
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var placeholderRegex = regexp.MustCompile(`{{\s*([a-zA-Z]+)\s*}}`)
+
+// Expand replaces every {{name}} inside the string with the value of the
+// name, placeholders without a value is left untouched
+func Expand(s string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholderRegex.FindStringSubmatch(match)[1]
+
+		value, exists := values[name]
+		if !exists {
+			return match
+		}
+
+		return value
+	})
+}
+
+// DateValues returns the date and time placeholders for the time
+func DateValues(t time.Time) map[string]string {
+	_, week := t.ISOWeek()
+
+	return map[string]string{
+		"date":     t.Format(time.DateOnly),
+		"time":     t.Format("15:04"),
+		"datetime": t.Format("2006-01-02 15:04"),
+		"year":     t.Format("2006"),
+		"month":    t.Format("01"),
+		"day":      t.Format("02"),
+		"week":     strconv.Itoa(week),
+		"weekday":  t.Weekday().String(),
+	}
+}
@@ -0,0 +1,31 @@
+package placeholder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	values := DateValues(time.Date(2024, time.March, 4, 9, 5, 0, 0, time.UTC))
+	values["project"] = "Beldum"
+
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Release {{date}}", "Release 2024-03-04"},
+		{"{{ weekday }} standup", "Monday standup"},
+		{"Week {{week}} of {{year}}", "Week 10 of 2024"},
+		{"{{datetime}}", "2024-03-04 09:05"},
+		{"{{project}}: {{month}}/{{day}}", "Beldum: 03/04"},
+		{"Keep {{unknown}} and {{date", "Keep {{unknown}} and {{date"},
+		{"No placeholders", "No placeholders"},
+	}
+
+	for _, test := range tests {
+		got := Expand(test.s, values)
+		if got != test.want {
+			t.Errorf("Expand(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+}
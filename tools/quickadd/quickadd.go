@@ -0,0 +1,297 @@
+// Package quickadd parses a single line of text into the parts of a task
+//
+// Supported tokens:
+//
+//	#tag          adds a tag, the tag is slugged
+//	>board        picks the board by name, a prefix or the initials is enough
+//	!priority     sets the priority, a prefix is enough (!h is high)
+//	due:when      sets the due date, see ParseDue
+//	\#word        escapes the token so it becomes part of the title
+//
+// Everything else is joined together as the title.
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nanoteck137/beldum/tools/utils"
+	"github.com/nanoteck137/beldum/types"
+)
+
+var (
+	ErrInvalidDue      = errors.New("quickadd: invalid due date")
+	ErrInvalidPriority = errors.New("quickadd: invalid priority")
+	ErrInvalidTag      = errors.New("quickadd: invalid tag")
+	ErrDuplicateToken  = errors.New("quickadd: token is used more than once")
+	ErrMissingTitle    = errors.New("quickadd: missing title")
+
+	ErrUnknownBoard   = errors.New("quickadd: unknown board")
+	ErrAmbiguousBoard = errors.New("quickadd: ambiguous board")
+)
+
+type Result struct {
+	Title string
+	Tags  []string
+
+	// NOTE(patrik): The board query as written, see MatchBoard
+	Board string
+
+	// NOTE(patrik): Formatted the way utils.ParseDue expects, dates with time
+	// is in the local time of now
+	Due string
+
+	Priority types.TaskPriority
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	if len(s) < 3 {
+		return 0, false
+	}
+
+	weekday, exists := weekdays[s[:3]]
+	if !exists {
+		return 0, false
+	}
+
+	// NOTE(patrik): Accepts both the short and the full name
+	full := strings.ToLower(weekday.String())
+	if s != s[:3] && s != full {
+		return 0, false
+	}
+
+	return weekday, true
+}
+
+func parseClock(s string) (int, int, bool) {
+	hourStr, minuteStr, hasMinute := strings.Cut(s, ":")
+
+	hour, err := strconv.Atoi(hourStr)
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, false
+	}
+
+	minute := 0
+	if hasMinute {
+		minute, err = strconv.Atoi(minuteStr)
+		if err != nil || len(minuteStr) != 2 || minute < 0 || minute > 59 {
+			return 0, 0, false
+		}
+	}
+
+	return hour, minute, true
+}
+
+// ParseDue resolves the due date relative to now, the date is calculated in
+// the location of now. Supported values:
+//
+//	today, tomorrow
+//	mon..sun or monday..sunday, the next such day with today included
+//	+3d, +2w, +1m
+//	2006-01-02
+//
+// A time can be added with @, for example fri@15:30 or tomorrow@9.
+func ParseDue(s string, now time.Time) (string, error) {
+	s = strings.ToLower(s)
+
+	date, clock, hasTime := strings.Cut(s, "@")
+
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+
+	var t time.Time
+
+	switch {
+	case date == "today":
+		t = today
+	case date == "tomorrow":
+		t = today.AddDate(0, 0, 1)
+	case strings.HasPrefix(date, "+") && len(date) > 2:
+		n, err := strconv.Atoi(date[1 : len(date)-1])
+		if err != nil || n < 0 {
+			return "", ErrInvalidDue
+		}
+
+		switch date[len(date)-1] {
+		case 'd':
+			t = today.AddDate(0, 0, n)
+		case 'w':
+			t = today.AddDate(0, 0, n*7)
+		case 'm':
+			t = today.AddDate(0, n, 0)
+		default:
+			return "", ErrInvalidDue
+		}
+	default:
+		if weekday, ok := parseWeekday(date); ok {
+			days := (int(weekday) - int(today.Weekday()) + 7) % 7
+			t = today.AddDate(0, 0, days)
+			break
+		}
+
+		parsed, err := time.ParseInLocation(utils.DueDateLayout, date, now.Location())
+		if err != nil {
+			return "", ErrInvalidDue
+		}
+
+		t = parsed
+	}
+
+	if !hasTime {
+		return t.Format(utils.DueDateLayout), nil
+	}
+
+	hour, minute, ok := parseClock(clock)
+	if !ok {
+		return "", ErrInvalidDue
+	}
+
+	t = time.Date(t.Year(), t.Month(), t.Day(), hour, minute, 0, 0, t.Location())
+	return t.Format("2006-01-02T15:04"), nil
+}
+
+func parsePriority(s string) (types.TaskPriority, error) {
+	s = strings.ToLower(s)
+
+	for _, name := range types.TaskPriorityNames() {
+		if strings.HasPrefix(name, s) {
+			priority, _ := types.ParseTaskPriority(name)
+			return priority, nil
+		}
+	}
+
+	return types.TaskPriorityNone, ErrInvalidPriority
+}
+
+func tokenError(err error, token string) error {
+	return fmt.Errorf("%w: %q", err, token)
+}
+
+// Parse parses the line, now is used to resolve the relative due dates
+func Parse(line string, now time.Time) (Result, error) {
+	res := Result{
+		Tags: []string{},
+	}
+
+	var title []string
+	hasPriority := false
+
+	for _, token := range strings.Fields(line) {
+		switch {
+		case strings.HasPrefix(token, `\`) && len(token) > 1:
+			title = append(title, token[1:])
+		case strings.HasPrefix(token, "#") && len(token) > 1:
+			tag := utils.Slug(token[1:])
+			if tag == "" {
+				return Result{}, tokenError(ErrInvalidTag, token)
+			}
+
+			if !slices.Contains(res.Tags, tag) {
+				res.Tags = append(res.Tags, tag)
+			}
+		case strings.HasPrefix(token, ">") && len(token) > 1:
+			if res.Board != "" {
+				return Result{}, tokenError(ErrDuplicateToken, token)
+			}
+
+			res.Board = token[1:]
+		case strings.HasPrefix(token, "!") && len(token) > 1:
+			if hasPriority {
+				return Result{}, tokenError(ErrDuplicateToken, token)
+			}
+
+			priority, err := parsePriority(token[1:])
+			if err != nil {
+				return Result{}, tokenError(err, token)
+			}
+
+			res.Priority = priority
+			hasPriority = true
+		case strings.HasPrefix(strings.ToLower(token), "due:") && len(token) > 4:
+			if res.Due != "" {
+				return Result{}, tokenError(ErrDuplicateToken, token)
+			}
+
+			due, err := ParseDue(token[4:], now)
+			if err != nil {
+				return Result{}, tokenError(err, token)
+			}
+
+			res.Due = due
+		default:
+			title = append(title, token)
+		}
+	}
+
+	res.Title = strings.Join(title, " ")
+	if res.Title == "" {
+		return Result{}, ErrMissingTitle
+	}
+
+	return res, nil
+}
+
+func initials(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(name) {
+		b.WriteString(word[:1])
+	}
+
+	return b.String()
+}
+
+// MatchBoard returns the index of the board the query points to. An exact
+// name wins over a name prefix which wins over the initials of the name,
+// for example "wip" matches "Work in progress". The match is case
+// insensitive.
+func MatchBoard(names []string, query string) (int, error) {
+	query = strings.ToLower(query)
+
+	matchers := []func(name string) bool{
+		func(name string) bool {
+			return name == query || utils.Slug(name) == query
+		},
+		func(name string) bool {
+			return strings.HasPrefix(name, query) || strings.HasPrefix(utils.Slug(name), query)
+		},
+		func(name string) bool {
+			return initials(name) == query
+		},
+	}
+
+	for _, match := range matchers {
+		index := -1
+
+		for i, name := range names {
+			if !match(strings.ToLower(name)) {
+				continue
+			}
+
+			if index != -1 {
+				return -1, tokenError(ErrAmbiguousBoard, query)
+			}
+
+			index = i
+		}
+
+		if index != -1 {
+			return index, nil
+		}
+	}
+
+	return -1, tokenError(ErrUnknownBoard, query)
+}
@@ -0,0 +1,144 @@
+package quickadd
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nanoteck137/beldum/types"
+)
+
+// NOTE(patrik): Friday
+var now = time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	res, err := Parse(`Fix login redirect #bug #Auth #bug >wip due:fri !high \#1`, now)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if res.Title != "Fix login redirect #1" {
+		t.Errorf("Title = %q", res.Title)
+	}
+
+	if !slices.Equal(res.Tags, []string{"bug", "auth"}) {
+		t.Errorf("Tags = %v", res.Tags)
+	}
+
+	if res.Board != "wip" {
+		t.Errorf("Board = %q", res.Board)
+	}
+
+	if res.Due != "2026-10-16" {
+		t.Errorf("Due = %q", res.Due)
+	}
+
+	if res.Priority != types.TaskPriorityHigh {
+		t.Errorf("Priority = %v", res.Priority)
+	}
+
+	invalid := []struct {
+		line string
+		err  error
+	}{
+		{"", ErrMissingTitle},
+		{"#bug >wip", ErrMissingTitle},
+		{"Task !huge", ErrInvalidPriority},
+		{"Task !high !low", ErrDuplicateToken},
+		{"Task >a >b", ErrDuplicateToken},
+		{"Task due:someday", ErrInvalidDue},
+		{"Task #???", ErrInvalidTag},
+	}
+
+	for _, test := range invalid {
+		_, err := Parse(test.line, now)
+		if !errors.Is(err, test.err) {
+			t.Errorf("Parse(%q) = %v, want %v", test.line, err, test.err)
+		}
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"today", "2026-10-16"},
+		{"Tomorrow", "2026-10-17"},
+		{"fri", "2026-10-16"},
+		{"friday", "2026-10-16"},
+		{"mon", "2026-10-19"},
+		{"thu", "2026-10-22"},
+		{"+3d", "2026-10-19"},
+		{"+2w", "2026-10-30"},
+		{"+1m", "2026-11-16"},
+		{"2027-01-05", "2027-01-05"},
+		{"tomorrow@9", "2026-10-17T09:00"},
+		{"mon@15:30", "2026-10-19T15:30"},
+	}
+
+	for _, test := range tests {
+		got, err := ParseDue(test.s, now)
+		if err != nil {
+			t.Errorf("ParseDue(%q) failed: %v", test.s, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseDue(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+
+	// NOTE(patrik): Still thursday in the location of now
+	loc := time.FixedZone("UTC-10", -10*60*60)
+	got, err := ParseDue("today", time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC).In(loc))
+	if err != nil || got != "2026-10-15" {
+		t.Errorf("ParseDue(today) = %q, %v, want 2026-10-15", got, err)
+	}
+
+	for _, s := range []string{"", "fr", "fridays", "+d", "+3y", "-3d", "tomorrow@25", "today@9:5", "2026-13-01"} {
+		_, err := ParseDue(s, now)
+		if !errors.Is(err, ErrInvalidDue) {
+			t.Errorf("ParseDue(%q) = %v, want ErrInvalidDue", s, err)
+		}
+	}
+}
+
+func TestMatchBoard(t *testing.T) {
+	names := []string{"Backlog", "Work in progress", "Review", "Released", "Done"}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"backlog", 0},
+		{"back", 0},
+		{"wip", 1},
+		{"work-in", 1},
+		{"rev", 2},
+		{"DONE", 4},
+	}
+
+	for _, test := range tests {
+		got, err := MatchBoard(names, test.query)
+		if err != nil {
+			t.Errorf("MatchBoard(%q) failed: %v", test.query, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("MatchBoard(%q) = %d, want %d", test.query, got, test.want)
+		}
+	}
+
+	_, err := MatchBoard(names, "re")
+	if !errors.Is(err, ErrAmbiguousBoard) {
+		t.Errorf("MatchBoard(re) = %v, want ErrAmbiguousBoard", err)
+	}
+
+	_, err = MatchBoard(names, "archive")
+	if !errors.Is(err, ErrUnknownBoard) {
+		t.Errorf("MatchBoard(archive) = %v, want ErrUnknownBoard", err)
+	}
+}
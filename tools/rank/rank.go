@@ -0,0 +1,247 @@
+package rank
+
+import (
+	"errors"
+	"strings"
+)
+
+// NOTE(patrik): Ranks are fractional indexing keys, an integer part where
+// the first character encodes the length followed by a fraction. Both
+// parts use the base62 digits in ASCII order so comparing the keys as
+// strings gives the order. A new key can always be created between two
+// others so moving a task only needs to update a single row.
+
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var rankSmallestInteger = "A" + strings.Repeat("0", 26)
+
+var ErrInvalidRank = errors.New("rank: invalid rank")
+
+func rankIntegerLength(head byte) (int, error) {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2, nil
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2, nil
+	}
+
+	return 0, ErrInvalidRank
+}
+
+func rankSplit(rank string) (string, string, error) {
+	if rank == "" {
+		return "", "", ErrInvalidRank
+	}
+
+	n, err := rankIntegerLength(rank[0])
+	if err != nil {
+		return "", "", err
+	}
+
+	if n > len(rank) {
+		return "", "", ErrInvalidRank
+	}
+
+	integer := rank[:n]
+	fraction := rank[n:]
+
+	if rank == rankSmallestInteger || strings.HasSuffix(fraction, "0") {
+		return "", "", ErrInvalidRank
+	}
+
+	for i := 1; i < len(rank); i++ {
+		if strings.IndexByte(rankDigits, rank[i]) == -1 {
+			return "", "", ErrInvalidRank
+		}
+	}
+
+	return integer, fraction, nil
+}
+
+// rankMidpoint returns a fraction between a and b, an empty b means that
+// there is no upper bound
+func rankMidpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) {
+			da := byte('0')
+			if n < len(a) {
+				da = a[n]
+			}
+
+			if da != b[n] {
+				break
+			}
+
+			n++
+		}
+
+		if n > 0 {
+			return b[:n] + rankMidpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	digitA := 0
+	if a != "" {
+		digitA = strings.IndexByte(rankDigits, a[0])
+	}
+
+	digitB := len(rankDigits)
+	if b != "" {
+		digitB = strings.IndexByte(rankDigits, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(rankDigits[(digitA+digitB+1)/2])
+	}
+
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if a != "" {
+		rest = a[1:]
+	}
+
+	return string(rankDigits[digitA]) + rankMidpoint(rest, "")
+}
+
+func rankIncrementInteger(x string) string {
+	head := x[0]
+	digits := []byte(x[1:])
+
+	carry := true
+	for i := len(digits) - 1; carry && i >= 0; i-- {
+		d := strings.IndexByte(rankDigits, digits[i]) + 1
+		if d == len(rankDigits) {
+			digits[i] = rankDigits[0]
+		} else {
+			digits[i] = rankDigits[d]
+			carry = false
+		}
+	}
+
+	if !carry {
+		return string(head) + string(digits)
+	}
+
+	switch head {
+	case 'Z':
+		return "a" + string(rankDigits[0])
+	case 'z':
+		return ""
+	}
+
+	head++
+	if head > 'a' {
+		digits = append(digits, rankDigits[0])
+	} else {
+		digits = digits[:len(digits)-1]
+	}
+
+	return string(head) + string(digits)
+}
+
+func rankDecrementInteger(x string) string {
+	head := x[0]
+	digits := []byte(x[1:])
+
+	borrow := true
+	for i := len(digits) - 1; borrow && i >= 0; i-- {
+		d := strings.IndexByte(rankDigits, digits[i]) - 1
+		if d == -1 {
+			digits[i] = rankDigits[len(rankDigits)-1]
+		} else {
+			digits[i] = rankDigits[d]
+			borrow = false
+		}
+	}
+
+	if !borrow {
+		return string(head) + string(digits)
+	}
+
+	switch head {
+	case 'a':
+		return "Z" + string(rankDigits[len(rankDigits)-1])
+	case 'A':
+		return ""
+	}
+
+	head--
+	if head < 'Z' {
+		digits = append(digits, rankDigits[len(rankDigits)-1])
+	} else {
+		digits = digits[:len(digits)-1]
+	}
+
+	return string(head) + string(digits)
+}
+
+// Between creates a rank that sorts between a and b, an empty a means
+// the start of the list and an empty b means the end of the list
+func Between(a, b string) (string, error) {
+	var ia, fa, ib, fb string
+	var err error
+
+	if a != "" {
+		ia, fa, err = rankSplit(a)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if b != "" {
+		ib, fb, err = rankSplit(b)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if a != "" && b != "" && a >= b {
+		return "", ErrInvalidRank
+	}
+
+	switch {
+	case a == "" && b == "":
+		return "a" + string(rankDigits[0]), nil
+	case a == "":
+		if ib == rankSmallestInteger {
+			return ib + rankMidpoint("", fb), nil
+		}
+
+		if ib < b {
+			return ib, nil
+		}
+
+		res := rankDecrementInteger(ib)
+		if res == "" {
+			return "", ErrInvalidRank
+		}
+
+		return res, nil
+	case b == "":
+		res := rankIncrementInteger(ia)
+		if res == "" {
+			return ia + rankMidpoint(fa, ""), nil
+		}
+
+		return res, nil
+	}
+
+	if ia == ib {
+		return ia + rankMidpoint(fa, fb), nil
+	}
+
+	i := rankIncrementInteger(ia)
+	if i == "" {
+		return "", ErrInvalidRank
+	}
+
+	if i < b {
+		return i, nil
+	}
+
+	return ia + rankMidpoint(fa, ""), nil
+}
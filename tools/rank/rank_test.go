@@ -0,0 +1,110 @@
+package rank
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"", "", "a0"},
+		{"", "a0", "Zz"},
+		{"a0", "", "a1"},
+		{"a1", "", "a2"},
+		{"a0", "a1", "a0V"},
+		{"a0V", "a1", "a0l"},
+		{"Zz", "a0", "ZzV"},
+		{"Zz", "a1", "a0"},
+		{"az", "", "b00"},
+		{"a0", "a0V", "a0G"},
+		{"b125", "b129", "b127"},
+		{"a00000001V", "a00000002V", "a00000002"},
+	}
+
+	for _, test := range tests {
+		got, err := Between(test.a, test.b)
+		if err != nil {
+			t.Errorf("Between(%q, %q) returned error: %v", test.a, test.b, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("Between(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"a1", "a0"},
+		{"a0", "a0"},
+		{"a", ""},
+		{"a00", ""},
+		{"a0!", ""},
+		{"", "0"},
+	}
+
+	for _, test := range tests {
+		_, err := Between(test.a, test.b)
+		if !errors.Is(err, ErrInvalidRank) {
+			t.Errorf("Between(%q, %q) expected ErrInvalidRank, got %v", test.a, test.b, err)
+		}
+	}
+}
+
+func TestBetweenKeepsOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	ranks := []string{}
+	for i := 0; i < 1000; i++ {
+		idx := r.Intn(len(ranks) + 1)
+
+		a := ""
+		if idx > 0 {
+			a = ranks[idx-1]
+		}
+
+		b := ""
+		if idx < len(ranks) {
+			b = ranks[idx]
+		}
+
+		rank, err := Between(a, b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q) returned error: %v", a, b, err)
+		}
+
+		if (a != "" && rank <= a) || (b != "" && rank >= b) {
+			t.Fatalf("Between(%q, %q) = %q is out of order", a, b, rank)
+		}
+
+		ranks = slices.Insert(ranks, idx, rank)
+	}
+
+	if !slices.IsSorted(ranks) {
+		t.Fatal("ranks is not sorted")
+	}
+}
+
+func TestBetweenAppendStaysShort(t *testing.T) {
+	rank := ""
+	for i := 0; i < 10000; i++ {
+		next, err := Between(rank, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rank = next
+	}
+
+	if len(rank) > 4 {
+		t.Errorf("rank after 10000 appends is too long: %q", rank)
+	}
+}
@@ -0,0 +1,341 @@
+// Package recurrence implements a subset of the iCalendar RRULE format
+//
+// Supported parts:
+//
+//	FREQ=DAILY|WEEKLY|MONTHLY
+//	INTERVAL=N
+//	BYDAY=MO,TU (weekly) or BYDAY=1MO,-1FR (monthly)
+//	BYMONTHDAY=15 or BYMONTHDAY=-1 (monthly)
+//	BYHOUR=9
+//	BYMINUTE=30
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Freq string
+
+const (
+	FreqDaily   Freq = "DAILY"
+	FreqWeekly  Freq = "WEEKLY"
+	FreqMonthly Freq = "MONTHLY"
+)
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Day is a weekday with an optional ordinal, the ordinal is only used by
+// monthly rules where 1MO is the first monday and -1MO the last monday
+type Day struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+type Rule struct {
+	Freq       Freq
+	Interval   int
+	ByDay      []Day
+	ByMonthDay []int
+	Hour       int
+	Minute     int
+}
+
+var ErrInvalidRule = errors.New("recurrence: invalid rule")
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
+
+func parseDay(s string) (Day, error) {
+	if len(s) < 2 {
+		return Day{}, invalid("invalid day %q", s)
+	}
+
+	weekday, exists := weekdays[s[len(s)-2:]]
+	if !exists {
+		return Day{}, invalid("invalid day %q", s)
+	}
+
+	day := Day{
+		Weekday: weekday,
+	}
+
+	if prefix := s[:len(s)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return Day{}, invalid("invalid day %q", s)
+		}
+
+		day.Ordinal = ordinal
+	}
+
+	return day, nil
+}
+
+func parseInt(name, s string, min, max int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil || i < min || i > max {
+		return 0, invalid("invalid %s %q", name, s)
+	}
+
+	return i, nil
+}
+
+// Parse parses a rule like "FREQ=WEEKLY;BYDAY=MO,WE;BYHOUR=9", an optional
+// "RRULE:" prefix is allowed
+func Parse(s string) (Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+
+	rule := Rule{
+		Interval: 1,
+	}
+
+	seen := make(map[string]bool)
+
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(part, "=")
+		if !found || value == "" {
+			return Rule{}, invalid("invalid part %q", part)
+		}
+
+		name = strings.ToUpper(name)
+		value = strings.ToUpper(value)
+
+		if seen[name] {
+			return Rule{}, invalid("duplicate part %q", name)
+		}
+		seen[name] = true
+
+		var err error
+
+		switch name {
+		case "FREQ":
+			rule.Freq = Freq(value)
+			switch rule.Freq {
+			case FreqDaily, FreqWeekly, FreqMonthly:
+			default:
+				return Rule{}, invalid("unsupported frequency %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = parseInt("interval", value, 1, 1000)
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				day, err := parseDay(d)
+				if err != nil {
+					return Rule{}, err
+				}
+
+				rule.ByDay = append(rule.ByDay, day)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				day, err := parseInt("month day", d, -31, 31)
+				if err != nil {
+					return Rule{}, err
+				}
+
+				if day == 0 {
+					return Rule{}, invalid("invalid month day %q", d)
+				}
+
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "BYHOUR":
+			rule.Hour, err = parseInt("hour", value, 0, 23)
+		case "BYMINUTE":
+			rule.Minute, err = parseInt("minute", value, 0, 59)
+		default:
+			return Rule{}, invalid("unsupported part %q", name)
+		}
+
+		if err != nil {
+			return Rule{}, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return Rule{}, invalid("missing FREQ")
+	}
+
+	switch rule.Freq {
+	case FreqDaily:
+		if len(rule.ByDay) > 0 || len(rule.ByMonthDay) > 0 {
+			return Rule{}, invalid("BYDAY and BYMONTHDAY is not supported with DAILY")
+		}
+	case FreqWeekly:
+		if len(rule.ByMonthDay) > 0 {
+			return Rule{}, invalid("BYMONTHDAY is not supported with WEEKLY")
+		}
+
+		for _, day := range rule.ByDay {
+			if day.Ordinal != 0 {
+				return Rule{}, invalid("ordinal days is not supported with WEEKLY")
+			}
+		}
+	}
+
+	return rule, nil
+}
+
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			name := strings.ToUpper(day.Weekday.String()[:2])
+			if day.Ordinal != 0 {
+				name = strconv.Itoa(day.Ordinal) + name
+			}
+
+			days[i] = name
+		}
+
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+
+	parts = append(parts, "BYHOUR="+strconv.Itoa(r.Hour))
+	parts = append(parts, "BYMINUTE="+strconv.Itoa(r.Minute))
+
+	return strings.Join(parts, ";")
+}
+
+// civil converts the date part of the time to a UTC date so the number of
+// days between dates can be calculated without daylight saving time issues
+func civil(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (r Rule) matchesDay(start, day time.Time) bool {
+	switch r.Freq {
+	case FreqDaily:
+		return daysBetween(start, day)%r.Interval == 0
+	case FreqWeekly:
+		weeks := daysBetween(weekStart(start), weekStart(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+
+		return slices.ContainsFunc(r.ByDay, func(d Day) bool {
+			return d.Weekday == day.Weekday()
+		})
+	case FreqMonthly:
+		months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+
+		if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+			return day.Day() == start.Day()
+		}
+
+		last := daysInMonth(day)
+
+		for _, d := range r.ByMonthDay {
+			if d > 0 && day.Day() == d {
+				return true
+			}
+
+			if d < 0 && day.Day() == last+d+1 {
+				return true
+			}
+		}
+
+		for _, d := range r.ByDay {
+			if d.Weekday != day.Weekday() {
+				continue
+			}
+
+			switch {
+			case d.Ordinal == 0:
+				return true
+			case d.Ordinal > 0 && (day.Day()-1)/7+1 == d.Ordinal:
+				return true
+			case d.Ordinal < 0 && (last-day.Day())/7+1 == -d.Ordinal:
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Next returns the first occurrence after the time, start is the time the
+// rule started and is used as the anchor for the interval. The occurrences
+// is calculated in the location of start. Returns false if there is no
+// occurrence.
+func (r Rule) Next(start, after time.Time) (time.Time, bool) {
+	loc := start.Location()
+	after = after.In(loc)
+
+	startDay := civil(start)
+
+	day := civil(after)
+	if day.Before(startDay) {
+		day = startDay
+	}
+
+	// NOTE(patrik): Checking day by day is simple and fast enough, the
+	// limit covers the longest possible gap between two occurrences
+	limit := 366 * (r.Interval + 1)
+
+	for i := 0; i < limit; i++ {
+		if r.matchesDay(startDay, day) {
+			t := time.Date(day.Year(), day.Month(), day.Day(), r.Hour, r.Minute, 0, 0, loc)
+			if t.After(after) && !t.Before(start) {
+				return t, true
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,125 @@
+package recurrence
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"FREQ=DAILY", "FREQ=DAILY;BYHOUR=0;BYMINUTE=0"},
+		{"RRULE:freq=weekly;byday=mo,fr;byhour=9", "FREQ=WEEKLY;BYDAY=MO,FR;BYHOUR=9;BYMINUTE=0"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;BYMINUTE=30", "FREQ=MONTHLY;BYMONTHDAY=-1;BYHOUR=0;BYMINUTE=30"},
+		{"FREQ=MONTHLY;INTERVAL=2;BYDAY=1MO,-1FR", "FREQ=MONTHLY;INTERVAL=2;BYDAY=1MO,-1FR;BYHOUR=0;BYMINUTE=0"},
+	}
+
+	for _, test := range tests {
+		rule, err := Parse(test.s)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", test.s, err)
+			continue
+		}
+
+		if got := rule.String(); got != test.want {
+			t.Errorf("Parse(%q) = %q, want %q", test.s, got, test.want)
+		}
+	}
+
+	invalidRules := []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;COUNT=2",
+	}
+
+	for _, s := range invalidRules {
+		_, err := Parse(s)
+		if !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("Parse(%q) = %v, want ErrInvalidRule", s, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	date := func(s string) time.Time {
+		t, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			panic(err)
+		}
+
+		return t
+	}
+
+	tests := []struct {
+		rule  string
+		start string
+		after string
+		want  string
+	}{
+		// NOTE(patrik): 2024-01-01 is a monday
+		{"FREQ=DAILY;BYHOUR=9", "2024-01-01 00:00", "2024-01-01 00:00", "2024-01-01 09:00"},
+		{"FREQ=DAILY;BYHOUR=9", "2024-01-01 00:00", "2024-01-01 09:00", "2024-01-02 09:00"},
+		{"FREQ=DAILY;INTERVAL=3", "2024-01-01 00:00", "2024-01-02 00:00", "2024-01-04 00:00"},
+		{"FREQ=DAILY;BYHOUR=9", "2024-01-01 10:00", "2024-01-01 10:00", "2024-01-02 09:00"},
+		{"FREQ=WEEKLY", "2024-01-03 00:00", "2024-01-04 00:00", "2024-01-10 00:00"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", "2024-01-01 00:00", "2024-01-02 00:00", "2024-01-05 00:00"},
+		{"FREQ=WEEKLY;BYDAY=MO,FR", "2024-01-01 00:00", "2024-01-05 00:00", "2024-01-08 00:00"},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", "2024-01-01 00:00", "2024-01-01 00:00", "2024-01-15 00:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=15", "2024-01-20 00:00", "2024-01-20 00:00", "2024-02-15 00:00"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", "2024-01-01 00:00", "2024-01-31 00:00", "2024-02-29 00:00"},
+		{"FREQ=MONTHLY", "2024-01-31 00:00", "2024-01-31 00:00", "2024-03-31 00:00"},
+		{"FREQ=MONTHLY;BYDAY=1MO", "2024-01-01 00:00", "2024-01-01 00:00", "2024-02-05 00:00"},
+		{"FREQ=MONTHLY;BYDAY=-1FR", "2024-01-01 00:00", "2024-01-01 00:00", "2024-01-26 00:00"},
+		{"FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=1", "2024-01-01 00:00", "2024-01-01 00:00", "2024-04-01 00:00"},
+	}
+
+	for _, test := range tests {
+		rule, err := Parse(test.rule)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", test.rule, err)
+		}
+
+		got, ok := rule.Next(date(test.start), date(test.after))
+		if !ok {
+			t.Errorf("%s: Next(%s) found no occurrence", test.rule, test.after)
+			continue
+		}
+
+		if !got.Equal(date(test.want)) {
+			t.Errorf("%s: Next(%s) = %s, want %s", test.rule, test.after, got.Format("2006-01-02 15:04"), test.want)
+		}
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	rule, err := Parse("FREQ=DAILY;BYHOUR=9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE(patrik): Daylight saving time starts on 2024-03-31
+	start := time.Date(2024, time.March, 30, 0, 0, 0, 0, loc)
+	got, _ := rule.Next(start, time.Date(2024, time.March, 30, 10, 0, 0, 0, loc))
+
+	want := time.Date(2024, time.March, 31, 9, 0, 0, 0, loc)
+	if !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+const DueDateLayout = "2006-01-02"
+
+var ErrInvalidDue = errors.New("invalid due date")
+
+// ParseDue parses a due date in the form of "2006-01-02", "2006-01-02T15:04"
+// or RFC3339. Dates without time is returned as midnight UTC so the
+// calendar date stays the same for every timezone, times without an
+// offset is parsed in loc
+func ParseDue(s string, loc *time.Location) (time.Time, bool, error) {
+	t, err := time.Parse(DueDateLayout, s)
+	if err == nil {
+		return t, false, nil
+	}
+
+	t, err = time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, true, nil
+	}
+
+	t, err = time.ParseInLocation("2006-01-02T15:04", s, loc)
+	if err == nil {
+		return t, true, nil
+	}
+
+	return time.Time{}, false, ErrInvalidDue
+}
+
+// FormatDue is the reverse of ParseDue, dates without time is formatted
+// as "2006-01-02" and dates with time as RFC3339 in UTC
+func FormatDue(due int64, hasTime bool) string {
+	t := time.UnixMilli(due).UTC()
+	if hasTime {
+		return t.Format(time.RFC3339)
+	}
+
+	return t.Format(DueDateLayout)
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	ProjectKeyMinLength = 2
+	ProjectKeyMaxLength = 10
+)
+
+var ProjectKeyRegex = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+// CreateProjectKey derives a short uppercase key from the project name,
+// names with multiple words uses the initials
+func CreateProjectKey(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !(r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+	})
+
+	// NOTE(patrik): The key needs to start with a letter
+	for len(words) > 0 && !unicode.IsLetter(rune(words[0][0])) {
+		words = words[1:]
+	}
+
+	var key string
+	if len(words) >= ProjectKeyMinLength {
+		for _, word := range words {
+			key += word[:1]
+		}
+	} else if len(words) == 1 {
+		key = words[0]
+	}
+
+	key = strings.ToUpper(key)
+	if len(key) > 4 {
+		key = key[:4]
+	}
+
+	if len(key) < ProjectKeyMinLength {
+		return "PRJ"
+	}
+
+	return key
+}
+
+func FormatTaskKey(projectKey string, number int64) string {
+	return fmt.Sprintf("%s-%d", projectKey, number)
+}
+
+// ParseTaskKey splits a KEY-N task key, the project key is uppercased so the
+// lookup is case insensitive
+func ParseTaskKey(s string) (string, int64, bool) {
+	key, num, ok := strings.Cut(s, "-")
+	if !ok {
+		return "", 0, false
+	}
+
+	key = strings.ToUpper(key)
+	if !ProjectKeyRegex.MatchString(key) {
+		return "", 0, false
+	}
+
+	number, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || number <= 0 {
+		return "", 0, false
+	}
+
+	return key, number, true
+}
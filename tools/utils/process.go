@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"os/exec"
 	"path"
 )
@@ -77,3 +78,21 @@ func ProcessOriginalVersion(input string, outputDir, name string) (string, error
 
 	return filename, nil
 }
+
+// CreateResizedImage creates a version of the image that fits inside a
+// size x size box, smaller images keeps their size
+func CreateResizedImage(input string, output string, size int) error {
+	scale := fmt.Sprintf("scale='min(iw,%d)':'min(ih,%d)':force_original_aspect_ratio=decrease", size, size)
+
+	var args []string
+	args = append(args, "-y", "-i", input, "-vf", scale, "-frames:v", "1", output)
+
+	cmd := exec.Command("ffmpeg", args...)
+
+	err := cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -15,6 +15,18 @@ var CreateSmallId = createIdGenerator(8)
 var CreateProjectId = createIdGenerator(8)
 var CreateBoardId = createIdGenerator(8)
 var CreateTaskId = createIdGenerator(16)
+var CreateCommentId = createIdGenerator(16)
+var CreateChecklistId = createIdGenerator(16)
+var CreateChecklistItemId = createIdGenerator(16)
+var CreateRelationId = createIdGenerator(16)
+var CreateFieldId = createIdGenerator(16)
+var CreateTrashId = createIdGenerator(16)
+var CreateHistoryId = createIdGenerator(16)
+var CreateAttachmentId = createIdGenerator(16)
+var CreateTemplateId = createIdGenerator(16)
+var CreateTimeEntryId = createIdGenerator(16)
+var CreateSprintId = createIdGenerator(16)
+var CreateMilestoneId = createIdGenerator(16)
 
 var CreateApiTokenId = createIdGenerator(32)
 
@@ -12,6 +12,108 @@ const (
 	MediaTypeAcc       MediaType = "acc"
 )
 
+type TaskRelationType string
+
+const (
+	TaskRelationBlocks     TaskRelationType = "blocks"
+	TaskRelationRelatesTo  TaskRelationType = "relates-to"
+	TaskRelationDuplicates TaskRelationType = "duplicates"
+)
+
+type TaskPriority int
+
+const (
+	TaskPriorityNone TaskPriority = iota
+	TaskPriorityLow
+	TaskPriorityMedium
+	TaskPriorityHigh
+	TaskPriorityUrgent
+)
+
+var taskPriorityNames = []string{
+	TaskPriorityNone:   "none",
+	TaskPriorityLow:    "low",
+	TaskPriorityMedium: "medium",
+	TaskPriorityHigh:   "high",
+	TaskPriorityUrgent: "urgent",
+}
+
+func TaskPriorityNames() []string {
+	return taskPriorityNames
+}
+
+func ParseTaskPriority(s string) (TaskPriority, bool) {
+	for i, name := range taskPriorityNames {
+		if name == s {
+			return TaskPriority(i), true
+		}
+	}
+
+	return TaskPriorityNone, false
+}
+
+func (p TaskPriority) String() string {
+	if p < 0 || int(p) >= len(taskPriorityNames) {
+		return taskPriorityNames[TaskPriorityNone]
+	}
+
+	return taskPriorityNames[p]
+}
+
+type TrashType string
+
+const (
+	TrashTypeProject    TrashType = "project"
+	TrashTypeBoard      TrashType = "board"
+	TrashTypeTask       TrashType = "task"
+	TrashTypeAttachment TrashType = "attachment"
+)
+
+type FieldType string
+
+const (
+	FieldTypeText         FieldType = "text"
+	FieldTypeNumber       FieldType = "number"
+	FieldTypeDate         FieldType = "date"
+	FieldTypeSingleSelect FieldType = "single-select"
+	FieldTypeMultiSelect  FieldType = "multi-select"
+	FieldTypeCheckbox     FieldType = "checkbox"
+	FieldTypeURL          FieldType = "url"
+)
+
+func IsValidFieldType(t FieldType) bool {
+	switch t {
+	case FieldTypeText, FieldTypeNumber, FieldTypeDate, FieldTypeSingleSelect,
+		FieldTypeMultiSelect, FieldTypeCheckbox, FieldTypeURL:
+		return true
+	}
+
+	return false
+}
+
+func (t FieldType) HasOptions() bool {
+	return t == FieldTypeSingleSelect || t == FieldTypeMultiSelect
+}
+
+type MilestoneStatus string
+
+const (
+	MilestoneStatusPlanned   MilestoneStatus = "planned"
+	MilestoneStatusActive    MilestoneStatus = "active"
+	MilestoneStatusReleased  MilestoneStatus = "released"
+	MilestoneStatusCancelled MilestoneStatus = "cancelled"
+)
+
+func IsValidMilestoneStatus(s MilestoneStatus) bool {
+	switch s {
+	case MilestoneStatusPlanned, MilestoneStatusActive,
+		MilestoneStatusReleased, MilestoneStatusCancelled:
+		return true
+	}
+
+	return false
+}
+
 type Map map[string]any
 
 type WorkDir string
@@ -28,10 +130,19 @@ func (d WorkDir) SetupFile() string {
 	return path.Join(d.String(), "setup")
 }
 
+// ReferencesFile marks that the task references has been backfilled
+func (d WorkDir) ReferencesFile() string {
+	return path.Join(d.String(), "references")
+}
+
 func (d WorkDir) Trash() string {
 	return path.Join(d.String(), "trash")
 }
 
+func (d WorkDir) Attachments() string {
+	return path.Join(d.String(), "attachments")
+}
+
 type Change[T any] struct {
 	Value   T
 	Changed bool
@@ -20,6 +20,10 @@ export class ApiClient extends BaseApiClient {
     return this.request(`/api/v1/projects/${projectId}`, "GET", api.GetProjectById, z.any(), undefined, options)
   }
   
+  editProject(projectId: string, body: api.EditProjectBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
   createBoard(body: api.CreateBoardBody, options?: ExtraOptions) {
     return this.request("/api/v1/boards", "POST", api.CreateBoard, z.any(), body, options)
   }
@@ -44,12 +48,312 @@ export class ApiClient extends BaseApiClient {
     return this.request("/api/v1/tasks", "POST", api.CreateTask, z.any(), body, options)
   }
   
+  getTaskByKey(key: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/by-key/${key}`, "GET", api.GetTaskByKey, z.any(), undefined, options)
+  }
+  
+  editTask(taskId: string, body: api.EditTaskBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
   deleteTask(taskId: string, options?: ExtraOptions) {
     return this.request(`/api/v1/tasks/${taskId}`, "DELETE", z.undefined(), z.any(), undefined, options)
   }
   
-  moveTask(taskId: string, boardId: string, options?: ExtraOptions) {
-    return this.request(`/api/v1/tasks/${taskId}/move/${boardId}`, "POST", z.undefined(), z.any(), undefined, options)
+  moveTask(taskId: string, boardId: string, body: api.MoveTaskBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/move/${boardId}`, "POST", z.undefined(), z.any(), body, options)
+  }
+  
+  getTaskComments(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/comments`, "GET", api.GetTaskComments, z.any(), undefined, options)
+  }
+  
+  createTaskComment(taskId: string, body: api.CreateTaskCommentBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/comments`, "POST", api.CreateTaskComment, z.any(), body, options)
+  }
+  
+  editTaskComment(taskId: string, commentId: string, body: api.EditTaskCommentBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/comments/${commentId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteTaskComment(taskId: string, commentId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/comments/${commentId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskChecklists(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists`, "GET", api.GetTaskChecklists, z.any(), undefined, options)
+  }
+  
+  createChecklist(taskId: string, body: api.CreateChecklistBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists`, "POST", api.CreateChecklist, z.any(), body, options)
+  }
+  
+  editChecklist(taskId: string, checklistId: string, body: api.EditChecklistBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists/${checklistId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteChecklist(taskId: string, checklistId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists/${checklistId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  createChecklistItem(taskId: string, checklistId: string, body: api.CreateChecklistItemBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists/${checklistId}/items`, "POST", api.CreateChecklistItem, z.any(), body, options)
+  }
+  
+  editChecklistItem(taskId: string, checklistId: string, itemId: string, body: api.EditChecklistItemBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists/${checklistId}/items/${itemId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteChecklistItem(taskId: string, checklistId: string, itemId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/checklists/${checklistId}/items/${itemId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskChildren(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/children`, "GET", api.GetTaskChildren, z.any(), undefined, options)
+  }
+  
+  attachTaskChild(taskId: string, childId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/children/${childId}`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  detachTaskChild(taskId: string, childId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/children/${childId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskRelations(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/relations`, "GET", api.GetTaskRelations, z.any(), undefined, options)
+  }
+  
+  createTaskRelation(taskId: string, body: api.CreateTaskRelationBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/relations`, "POST", api.CreateTaskRelation, z.any(), body, options)
+  }
+  
+  deleteTaskRelation(taskId: string, relationId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/relations/${relationId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getProjectGraph(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/graph`, "GET", api.GetProjectGraph, z.any(), undefined, options)
+  }
+  
+  getProjectAgenda(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/agenda`, "GET", api.GetProjectAgenda, z.any(), undefined, options)
+  }
+  
+  getProjectFields(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/fields`, "GET", api.GetProjectFields, z.any(), undefined, options)
+  }
+  
+  createProjectField(projectId: string, body: api.CreateProjectFieldBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/fields`, "POST", api.CreateProjectField, z.any(), body, options)
+  }
+  
+  editProjectField(projectId: string, fieldId: string, body: api.EditProjectFieldBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/fields/${fieldId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteProjectField(projectId: string, fieldId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/fields/${fieldId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  setTaskField(taskId: string, fieldId: string, body: api.SetTaskFieldBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/fields/${fieldId}`, "PUT", z.undefined(), z.any(), body, options)
+  }
+  
+  removeTaskField(taskId: string, fieldId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/fields/${fieldId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  archiveTask(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/archive`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  unarchiveTask(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/unarchive`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTrash(options?: ExtraOptions) {
+    return this.request("/api/v1/trash", "GET", api.GetTrash, z.any(), undefined, options)
+  }
+  
+  restoreTrashItem(trashId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/trash/${trashId}/restore`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  purgeTrashItem(trashId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/trash/${trashId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  emptyTrash(options?: ExtraOptions) {
+    return this.request("/api/v1/trash", "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  deleteProject(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  deleteBoard(boardId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/boards/${boardId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskHistory(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/history`, "GET", api.GetTaskHistory, z.any(), undefined, options)
+  }
+  
+  revertTask(taskId: string, historyId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/history/${historyId}/revert`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskAttachments(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/attachments`, "GET", api.GetTaskAttachments, z.any(), undefined, options)
+  }
+  
+  deleteAttachment(attachmentId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/attachments/${attachmentId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskTemplates(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/templates`, "GET", api.GetTaskTemplates, z.any(), undefined, options)
+  }
+  
+  createTaskTemplate(projectId: string, body: api.CreateTaskTemplateBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/templates`, "POST", api.CreateTaskTemplate, z.any(), body, options)
+  }
+  
+  editTaskTemplate(projectId: string, templateId: string, body: api.EditTaskTemplateBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/templates/${templateId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteTaskTemplate(projectId: string, templateId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/templates/${templateId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  createTaskFromTemplate(projectId: string, templateId: string, body: api.CreateTaskFromTemplateBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/templates/${templateId}/tasks`, "POST", api.CreateTaskFromTemplate, z.any(), body, options)
+  }
+  
+  getTimer(options?: ExtraOptions) {
+    return this.request("/api/v1/timer", "GET", api.GetTimer, z.any(), undefined, options)
+  }
+  
+  startTimer(taskId: string, body: api.StartTimerBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/timer/start`, "POST", api.StartTimer, z.any(), body, options)
+  }
+  
+  stopTimer(options?: ExtraOptions) {
+    return this.request("/api/v1/timer/stop", "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskTimeEntries(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/time-entries`, "GET", api.GetTimeEntries, z.any(), undefined, options)
+  }
+  
+  createTimeEntry(taskId: string, body: api.CreateTimeEntryBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/time-entries`, "POST", api.CreateTimeEntry, z.any(), body, options)
+  }
+  
+  editTimeEntry(entryId: string, body: api.EditTimeEntryBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/time-entries/${entryId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteTimeEntry(entryId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/time-entries/${entryId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTimeReport(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/time-report`, "GET", api.TimeReport, z.any(), undefined, options)
+  }
+  
+  getSprints(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/sprints`, "GET", api.GetSprints, z.any(), undefined, options)
+  }
+  
+  createSprint(projectId: string, body: api.CreateSprintBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/sprints`, "POST", api.CreateSprint, z.any(), body, options)
+  }
+  
+  editSprint(sprintId: string, body: api.EditSprintBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/sprints/${sprintId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteSprint(sprintId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/sprints/${sprintId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getSprintBurndown(sprintId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/sprints/${sprintId}/burndown`, "GET", api.GetSprintBurndown, z.any(), undefined, options)
+  }
+  
+  closeSprint(sprintId: string, body: api.CloseSprintBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/sprints/${sprintId}/close`, "POST", api.CloseSprint, z.any(), body, options)
+  }
+  
+  getMilestones(projectId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/milestones`, "GET", api.GetMilestones, z.any(), undefined, options)
+  }
+  
+  createMilestone(projectId: string, body: api.CreateMilestoneBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/milestones`, "POST", api.CreateMilestone, z.any(), body, options)
+  }
+  
+  editMilestone(milestoneId: string, body: api.EditMilestoneBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/milestones/${milestoneId}`, "PATCH", z.undefined(), z.any(), body, options)
+  }
+  
+  deleteMilestone(milestoneId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/milestones/${milestoneId}`, "DELETE", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getMilestoneProgress(milestoneId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/milestones/${milestoneId}/progress`, "GET", api.MilestoneProgress, z.any(), undefined, options)
+  }
+  
+  getMilestoneChangelog(milestoneId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/milestones/${milestoneId}/changelog`, "GET", api.MilestoneChangelog, z.any(), undefined, options)
+  }
+  
+  moveTaskToProject(taskId: string, projectId: string, body: api.MoveTaskToProjectBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/move-to-project/${projectId}`, "POST", api.TransferTask, z.any(), body, options)
+  }
+  
+  duplicateTask(taskId: string, body: api.DuplicateTaskBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/duplicate`, "POST", api.TransferTask, z.any(), body, options)
+  }
+  
+  quickAddTask(projectId: string, body: api.QuickAddBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/quick-add`, "POST", api.QuickAdd, z.any(), body, options)
+  }
+  
+  getTaskBacklinks(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/backlinks`, "GET", api.GetTaskBacklinks, z.any(), undefined, options)
+  }
+  
+  bulkTasks(projectId: string, body: api.BulkTasksBody, options?: ExtraOptions) {
+    return this.request(`/api/v1/projects/${projectId}/tasks/bulk`, "POST", api.BulkTasks, z.any(), body, options)
+  }
+  
+  watchTask(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/watch`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  unwatchTask(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/unwatch`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getTaskWatchers(taskId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/tasks/${taskId}/watchers`, "GET", api.GetTaskWatchers, z.any(), undefined, options)
+  }
+  
+  watchBoard(boardId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/boards/${boardId}/watch`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  unwatchBoard(boardId: string, options?: ExtraOptions) {
+    return this.request(`/api/v1/boards/${boardId}/unwatch`, "POST", z.undefined(), z.any(), undefined, options)
+  }
+  
+  getUserSubscriptions(options?: ExtraOptions) {
+    return this.request("/api/v1/user/subscriptions", "GET", api.GetUserSubscriptions, z.any(), undefined, options)
   }
   
   signup(body: api.SignupBody, options?: ExtraOptions) {
@@ -87,4 +391,8 @@ export class ApiClient extends BaseApiClient {
   deleteApiToken(id: string, options?: ExtraOptions) {
     return this.request(`/api/v1/user/apitoken/${id}`, "DELETE", z.undefined(), z.any(), undefined, options)
   }
+  
+  uploadTaskAttachments(taskId: string, formData: FormData, options?: ExtraOptions) {
+    return this.requestWithFormData(`/api/v1/tasks/${taskId}/attachments`, "POST", api.UploadTaskAttachments, z.undefined(), formData, options)
+  }
 }
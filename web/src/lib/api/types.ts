@@ -8,12 +8,15 @@ export type CreateProject = z.infer<typeof CreateProject>;
 
 export const CreateProjectBody = z.object({
   name: z.string(),
+  key: z.string().optional(),
 });
 export type CreateProjectBody = z.infer<typeof CreateProjectBody>;
 
 export const Project = z.object({
   id: z.string(),
   name: z.string(),
+  key: z.string(),
+  autoArchiveDays: z.number().nullable(),
 });
 export type Project = z.infer<typeof Project>;
 
@@ -25,6 +28,12 @@ export type GetProjects = z.infer<typeof GetProjects>;
 export const GetProjectById = Project;
 export type GetProjectById = z.infer<typeof GetProjectById>;
 
+export const EditProjectBody = z.object({
+  name: z.string().nullable().optional(),
+  autoArchiveDays: z.number().nullable().optional(),
+});
+export type EditProjectBody = z.infer<typeof EditProjectBody>;
+
 export const CreateBoard = z.object({
   id: z.string(),
 });
@@ -50,12 +59,39 @@ export const GetAllProjectBoards = z.object({
 });
 export type GetAllProjectBoards = z.infer<typeof GetAllProjectBoards>;
 
+export const TaskFieldValue = z.object({
+  fieldId: z.string(),
+  name: z.string(),
+  type: z.string(),
+  text: z.string().nullable().optional(),
+  number: z.number().nullable().optional(),
+  checked: z.boolean().nullable().optional(),
+  options: z.array(z.string()).optional(),
+});
+export type TaskFieldValue = z.infer<typeof TaskFieldValue>;
+
 export const Task = z.object({
   id: z.string(),
+  key: z.string(),
   name: z.string(),
+  description: z.string().nullable(),
+  descriptionHtml: z.string().nullable(),
   boardId: z.string(),
   boardName: z.string(),
+  parentId: z.string().nullable(),
+  due: z.string().nullable(),
+  priority: z.string(),
+  estimate: z.number().nullable(),
+  sprintId: z.string().nullable(),
+  milestoneId: z.string().nullable(),
   tags: z.array(z.string()),
+  checklistDone: z.number(),
+  checklistTotal: z.number(),
+  childrenDone: z.number(),
+  childrenTotal: z.number(),
+  blocked: z.boolean(),
+  archived: z.boolean(),
+  fields: z.array(TaskFieldValue),
   created: z.number(),
   updated: z.number(),
 });
@@ -91,11 +127,688 @@ export type CreateTask = z.infer<typeof CreateTask>;
 
 export const CreateTaskBody = z.object({
   title: z.string(),
+  description: z.string(),
   tags: z.array(z.string()),
+  due: z.string(),
+  priority: z.string(),
+  estimate: z.number().optional(),
+  sprintId: z.string().optional(),
+  milestoneId: z.string().optional(),
   boardId: z.string(),
 });
 export type CreateTaskBody = z.infer<typeof CreateTaskBody>;
 
+export const GetTaskByKey = Task;
+export type GetTaskByKey = z.infer<typeof GetTaskByKey>;
+
+export const EditTaskBody = z.object({
+  title: z.string().nullable().optional(),
+  description: z.string().nullable().optional(),
+  tags: z.array(z.string()).nullable().optional(),
+  due: z.string().nullable().optional(),
+  priority: z.string().nullable().optional(),
+  estimate: z.number().nullable().optional(),
+  sprintId: z.string().nullable().optional(),
+  milestoneId: z.string().nullable().optional(),
+  boardId: z.string().nullable().optional(),
+});
+export type EditTaskBody = z.infer<typeof EditTaskBody>;
+
+export const MoveTaskBody = z.object({
+  before: z.string().nullable().optional(),
+  after: z.string().nullable().optional(),
+});
+export type MoveTaskBody = z.infer<typeof MoveTaskBody>;
+
+export const CommentAuthor = z.object({
+  id: z.string(),
+  displayName: z.string(),
+});
+export type CommentAuthor = z.infer<typeof CommentAuthor>;
+
+export const TaskComment = z.object({
+  id: z.string(),
+  author: CommentAuthor,
+  content: z.string(),
+  parentId: z.string().nullable(),
+  depth: z.number(),
+  edited: z.number().nullable(),
+  created: z.number(),
+});
+export type TaskComment = z.infer<typeof TaskComment>;
+
+export const GetTaskComments = z.object({
+  comments: z.array(TaskComment),
+});
+export type GetTaskComments = z.infer<typeof GetTaskComments>;
+
+export const CreateTaskComment = z.object({
+  id: z.string(),
+});
+export type CreateTaskComment = z.infer<typeof CreateTaskComment>;
+
+export const CreateTaskCommentBody = z.object({
+  content: z.string(),
+  parentId: z.string().nullable().optional(),
+});
+export type CreateTaskCommentBody = z.infer<typeof CreateTaskCommentBody>;
+
+export const EditTaskCommentBody = z.object({
+  content: z.string(),
+});
+export type EditTaskCommentBody = z.infer<typeof EditTaskCommentBody>;
+
+export const ChecklistItem = z.object({
+  id: z.string(),
+  title: z.string(),
+  checked: z.boolean(),
+});
+export type ChecklistItem = z.infer<typeof ChecklistItem>;
+
+export const Checklist = z.object({
+  id: z.string(),
+  name: z.string(),
+  items: z.array(ChecklistItem),
+});
+export type Checklist = z.infer<typeof Checklist>;
+
+export const GetTaskChecklists = z.object({
+  checklists: z.array(Checklist),
+});
+export type GetTaskChecklists = z.infer<typeof GetTaskChecklists>;
+
+export const CreateChecklist = z.object({
+  id: z.string(),
+});
+export type CreateChecklist = z.infer<typeof CreateChecklist>;
+
+export const CreateChecklistBody = z.object({
+  name: z.string(),
+});
+export type CreateChecklistBody = z.infer<typeof CreateChecklistBody>;
+
+export const EditChecklistBody = z.object({
+  name: z.string().nullable().optional(),
+  order: z.number().nullable().optional(),
+});
+export type EditChecklistBody = z.infer<typeof EditChecklistBody>;
+
+export const CreateChecklistItem = z.object({
+  id: z.string(),
+});
+export type CreateChecklistItem = z.infer<typeof CreateChecklistItem>;
+
+export const CreateChecklistItemBody = z.object({
+  title: z.string(),
+  checked: z.boolean(),
+});
+export type CreateChecklistItemBody = z.infer<typeof CreateChecklistItemBody>;
+
+export const EditChecklistItemBody = z.object({
+  title: z.string().nullable().optional(),
+  checked: z.boolean().nullable().optional(),
+  order: z.number().nullable().optional(),
+});
+export type EditChecklistItemBody = z.infer<typeof EditChecklistItemBody>;
+
+export const GetTaskChildren = z.object({
+  tasks: z.array(Task),
+});
+export type GetTaskChildren = z.infer<typeof GetTaskChildren>;
+
+export const TaskRelation = z.object({
+  id: z.string(),
+  type: z.string(),
+  taskId: z.string(),
+  taskTitle: z.string(),
+});
+export type TaskRelation = z.infer<typeof TaskRelation>;
+
+export const GetTaskRelations = z.object({
+  relations: z.array(TaskRelation),
+});
+export type GetTaskRelations = z.infer<typeof GetTaskRelations>;
+
+export const CreateTaskRelation = z.object({
+  id: z.string(),
+});
+export type CreateTaskRelation = z.infer<typeof CreateTaskRelation>;
+
+export const CreateTaskRelationBody = z.object({
+  type: z.string(),
+  taskId: z.string(),
+});
+export type CreateTaskRelationBody = z.infer<typeof CreateTaskRelationBody>;
+
+export const GraphNode = z.object({
+  id: z.string(),
+  title: z.string(),
+  boardId: z.string(),
+  boardName: z.string(),
+  blocked: z.boolean(),
+});
+export type GraphNode = z.infer<typeof GraphNode>;
+
+export const GraphEdge = z.object({
+  from: z.string(),
+  to: z.string(),
+  type: z.string(),
+});
+export type GraphEdge = z.infer<typeof GraphEdge>;
+
+export const GetProjectGraph = z.object({
+  nodes: z.array(GraphNode),
+  edges: z.array(GraphEdge),
+  dot: z.string(),
+});
+export type GetProjectGraph = z.infer<typeof GetProjectGraph>;
+
+export const GetProjectAgenda = z.object({
+  overdue: z.array(Task),
+  today: z.array(Task),
+  thisWeek: z.array(Task),
+  later: z.array(Task),
+});
+export type GetProjectAgenda = z.infer<typeof GetProjectAgenda>;
+
+export const ProjectField = z.object({
+  id: z.string(),
+  name: z.string(),
+  type: z.string(),
+  options: z.array(z.string()),
+});
+export type ProjectField = z.infer<typeof ProjectField>;
+
+export const GetProjectFields = z.object({
+  fields: z.array(ProjectField),
+});
+export type GetProjectFields = z.infer<typeof GetProjectFields>;
+
+export const CreateProjectField = z.object({
+  id: z.string(),
+});
+export type CreateProjectField = z.infer<typeof CreateProjectField>;
+
+export const CreateProjectFieldBody = z.object({
+  name: z.string(),
+  type: z.string(),
+  options: z.array(z.string()).optional(),
+});
+export type CreateProjectFieldBody = z.infer<typeof CreateProjectFieldBody>;
+
+export const EditProjectFieldBody = z.object({
+  name: z.string().nullable().optional(),
+  options: z.array(z.string()).nullable().optional(),
+});
+export type EditProjectFieldBody = z.infer<typeof EditProjectFieldBody>;
+
+export const SetTaskFieldBody = z.object({
+  text: z.string().nullable().optional(),
+  number: z.number().nullable().optional(),
+  checked: z.boolean().nullable().optional(),
+  options: z.array(z.string()).optional(),
+});
+export type SetTaskFieldBody = z.infer<typeof SetTaskFieldBody>;
+
+export const TrashItem = z.object({
+  id: z.string(),
+  type: z.string(),
+  itemId: z.string(),
+  name: z.string(),
+  projectId: z.string(),
+  projectName: z.string(),
+  deleted: z.number(),
+  expires: z.number().nullable(),
+});
+export type TrashItem = z.infer<typeof TrashItem>;
+
+export const GetTrash = z.object({
+  items: z.array(TrashItem),
+});
+export type GetTrash = z.infer<typeof GetTrash>;
+
+export const TaskHistoryActor = z.object({
+  id: z.string(),
+  displayName: z.string(),
+});
+export type TaskHistoryActor = z.infer<typeof TaskHistoryActor>;
+
+export const TaskHistoryChange = z.object({
+  field: z.string(),
+  before: z.string().nullable(),
+  after: z.string().nullable(),
+});
+export type TaskHistoryChange = z.infer<typeof TaskHistoryChange>;
+
+export const TaskHistoryEntry = z.object({
+  id: z.string(),
+  actor: TaskHistoryActor,
+  changes: z.array(TaskHistoryChange),
+  created: z.number(),
+});
+export type TaskHistoryEntry = z.infer<typeof TaskHistoryEntry>;
+
+export const GetTaskHistory = z.object({
+  history: z.array(TaskHistoryEntry),
+});
+export type GetTaskHistory = z.infer<typeof GetTaskHistory>;
+
+export const Images = z.object({
+  original: z.string(),
+  small: z.string(),
+  medium: z.string(),
+  large: z.string(),
+});
+export type Images = z.infer<typeof Images>;
+
+export const Attachment = z.object({
+  id: z.string(),
+  taskId: z.string(),
+  filename: z.string(),
+  contentType: z.string(),
+  size: z.number(),
+  url: z.string(),
+  images: Images.nullable(),
+  created: z.number(),
+});
+export type Attachment = z.infer<typeof Attachment>;
+
+export const GetTaskAttachments = z.object({
+  attachments: z.array(Attachment),
+});
+export type GetTaskAttachments = z.infer<typeof GetTaskAttachments>;
+
+export const UploadTaskAttachments = z.object({
+  ids: z.array(z.string()),
+});
+export type UploadTaskAttachments = z.infer<typeof UploadTaskAttachments>;
+
+export const TaskTemplate = z.object({
+  id: z.string(),
+  name: z.string(),
+  title: z.string(),
+  description: z.string().nullable(),
+  boardId: z.string().nullable(),
+  tags: z.array(z.string()),
+  checklist: z.array(z.string()),
+  recurrence: z.string().nullable(),
+  nextOccurrence: z.number().nullable(),
+  created: z.number(),
+  updated: z.number(),
+});
+export type TaskTemplate = z.infer<typeof TaskTemplate>;
+
+export const GetTaskTemplates = z.object({
+  templates: z.array(TaskTemplate),
+});
+export type GetTaskTemplates = z.infer<typeof GetTaskTemplates>;
+
+export const CreateTaskTemplate = z.object({
+  id: z.string(),
+});
+export type CreateTaskTemplate = z.infer<typeof CreateTaskTemplate>;
+
+export const CreateTaskTemplateBody = z.object({
+  name: z.string(),
+  title: z.string(),
+  description: z.string().optional(),
+  boardId: z.string().optional(),
+  tags: z.array(z.string()).optional(),
+  checklist: z.array(z.string()).optional(),
+  recurrence: z.string().optional(),
+});
+export type CreateTaskTemplateBody = z.infer<typeof CreateTaskTemplateBody>;
+
+export const EditTaskTemplateBody = z.object({
+  name: z.string().nullable().optional(),
+  title: z.string().nullable().optional(),
+  description: z.string().nullable().optional(),
+  boardId: z.string().nullable().optional(),
+  tags: z.array(z.string()).nullable().optional(),
+  checklist: z.array(z.string()).nullable().optional(),
+  recurrence: z.string().nullable().optional(),
+});
+export type EditTaskTemplateBody = z.infer<typeof EditTaskTemplateBody>;
+
+export const CreateTaskFromTemplate = z.object({
+  id: z.string(),
+});
+export type CreateTaskFromTemplate = z.infer<typeof CreateTaskFromTemplate>;
+
+export const CreateTaskFromTemplateBody = z.object({
+  boardId: z.string().optional(),
+});
+export type CreateTaskFromTemplateBody = z.infer<typeof CreateTaskFromTemplateBody>;
+
+export const TimeEntryUser = z.object({
+  id: z.string(),
+  displayName: z.string(),
+});
+export type TimeEntryUser = z.infer<typeof TimeEntryUser>;
+
+export const TimeEntry = z.object({
+  id: z.string(),
+  taskId: z.string(),
+  user: TimeEntryUser,
+  description: z.string().nullable(),
+  started: z.number(),
+  ended: z.number().nullable(),
+  duration: z.number(),
+  created: z.number(),
+  updated: z.number(),
+});
+export type TimeEntry = z.infer<typeof TimeEntry>;
+
+export const GetTimer = z.object({
+  entry: TimeEntry.nullable(),
+});
+export type GetTimer = z.infer<typeof GetTimer>;
+
+export const StartTimer = z.object({
+  id: z.string(),
+});
+export type StartTimer = z.infer<typeof StartTimer>;
+
+export const StartTimerBody = z.object({
+  description: z.string().optional(),
+});
+export type StartTimerBody = z.infer<typeof StartTimerBody>;
+
+export const GetTimeEntries = z.object({
+  entries: z.array(TimeEntry),
+});
+export type GetTimeEntries = z.infer<typeof GetTimeEntries>;
+
+export const CreateTimeEntry = z.object({
+  id: z.string(),
+});
+export type CreateTimeEntry = z.infer<typeof CreateTimeEntry>;
+
+export const CreateTimeEntryBody = z.object({
+  description: z.string().optional(),
+  started: z.number(),
+  ended: z.number(),
+});
+export type CreateTimeEntryBody = z.infer<typeof CreateTimeEntryBody>;
+
+export const EditTimeEntryBody = z.object({
+  description: z.string().nullable().optional(),
+  started: z.number().nullable().optional(),
+  ended: z.number().nullable().optional(),
+});
+export type EditTimeEntryBody = z.infer<typeof EditTimeEntryBody>;
+
+export const TimeReportRow = z.object({
+  id: z.string(),
+  name: z.string(),
+  duration: z.number(),
+});
+export type TimeReportRow = z.infer<typeof TimeReportRow>;
+
+export const TimeReport = z.object({
+  from: z.number(),
+  to: z.number(),
+  groupBy: z.string(),
+  total: z.number(),
+  rows: z.array(TimeReportRow),
+});
+export type TimeReport = z.infer<typeof TimeReport>;
+
+export const Sprint = z.object({
+  id: z.string(),
+  name: z.string(),
+  start: z.string(),
+  end: z.string(),
+  closed: z.number().nullable(),
+  taskCount: z.number(),
+  taskDone: z.number(),
+  points: z.number(),
+  pointsDone: z.number(),
+  created: z.number(),
+  updated: z.number(),
+});
+export type Sprint = z.infer<typeof Sprint>;
+
+export const GetSprints = z.object({
+  sprints: z.array(Sprint),
+});
+export type GetSprints = z.infer<typeof GetSprints>;
+
+export const CreateSprint = z.object({
+  id: z.string(),
+});
+export type CreateSprint = z.infer<typeof CreateSprint>;
+
+export const CreateSprintBody = z.object({
+  name: z.string(),
+  start: z.string(),
+  end: z.string(),
+});
+export type CreateSprintBody = z.infer<typeof CreateSprintBody>;
+
+export const EditSprintBody = z.object({
+  name: z.string().nullable().optional(),
+  start: z.string().nullable().optional(),
+  end: z.string().nullable().optional(),
+});
+export type EditSprintBody = z.infer<typeof EditSprintBody>;
+
+export const SprintBurndownDay = z.object({
+  date: z.string(),
+  ideal: z.number(),
+  remaining: z.number().nullable(),
+  completed: z.number().nullable(),
+});
+export type SprintBurndownDay = z.infer<typeof SprintBurndownDay>;
+
+export const GetSprintBurndown = z.object({
+  points: z.number(),
+  days: z.array(SprintBurndownDay),
+});
+export type GetSprintBurndown = z.infer<typeof GetSprintBurndown>;
+
+export const CloseSprint = z.object({
+  nextSprintId: z.string().nullable(),
+  moved: z.number(),
+});
+export type CloseSprint = z.infer<typeof CloseSprint>;
+
+export const CloseSprintBody = z.object({
+  nextSprintId: z.string().optional(),
+});
+export type CloseSprintBody = z.infer<typeof CloseSprintBody>;
+
+export const Milestone = z.object({
+  id: z.string(),
+  name: z.string(),
+  description: z.string().nullable(),
+  targetDate: z.string().nullable(),
+  status: z.string(),
+  taskCount: z.number(),
+  taskDone: z.number(),
+  points: z.number(),
+  pointsDone: z.number(),
+  created: z.number(),
+  updated: z.number(),
+});
+export type Milestone = z.infer<typeof Milestone>;
+
+export const GetMilestones = z.object({
+  milestones: z.array(Milestone),
+});
+export type GetMilestones = z.infer<typeof GetMilestones>;
+
+export const CreateMilestone = z.object({
+  id: z.string(),
+});
+export type CreateMilestone = z.infer<typeof CreateMilestone>;
+
+export const CreateMilestoneBody = z.object({
+  name: z.string(),
+  description: z.string().optional(),
+  targetDate: z.string().optional(),
+  status: z.string().optional(),
+});
+export type CreateMilestoneBody = z.infer<typeof CreateMilestoneBody>;
+
+export const EditMilestoneBody = z.object({
+  name: z.string().nullable().optional(),
+  description: z.string().nullable().optional(),
+  targetDate: z.string().nullable().optional(),
+  status: z.string().nullable().optional(),
+});
+export type EditMilestoneBody = z.infer<typeof EditMilestoneBody>;
+
+export const MilestoneProgressBoard = z.object({
+  id: z.string(),
+  name: z.string(),
+  count: z.number(),
+});
+export type MilestoneProgressBoard = z.infer<typeof MilestoneProgressBoard>;
+
+export const MilestoneProgress = z.object({
+  taskCount: z.number(),
+  taskDone: z.number(),
+  points: z.number(),
+  pointsDone: z.number(),
+  percent: z.number(),
+  daysLeft: z.number().nullable(),
+  overdue: z.boolean(),
+  boards: z.array(MilestoneProgressBoard),
+});
+export type MilestoneProgress = z.infer<typeof MilestoneProgress>;
+
+export const ChangelogTask = z.object({
+  id: z.string(),
+  key: z.string(),
+  title: z.string(),
+});
+export type ChangelogTask = z.infer<typeof ChangelogTask>;
+
+export const ChangelogGroup = z.object({
+  tag: z.string(),
+  tasks: z.array(ChangelogTask),
+});
+export type ChangelogGroup = z.infer<typeof ChangelogGroup>;
+
+export const MilestoneChangelog = z.object({
+  name: z.string(),
+  targetDate: z.string().nullable(),
+  groups: z.array(ChangelogGroup),
+});
+export type MilestoneChangelog = z.infer<typeof MilestoneChangelog>;
+
+export const TransferTask = z.object({
+  id: z.string(),
+  key: z.string(),
+});
+export type TransferTask = z.infer<typeof TransferTask>;
+
+export const MoveTaskToProjectBody = z.object({
+  boardId: z.string().optional(),
+});
+export type MoveTaskToProjectBody = z.infer<typeof MoveTaskToProjectBody>;
+
+export const DuplicateTaskBody = z.object({
+  projectId: z.string().optional(),
+  boardId: z.string().optional(),
+});
+export type DuplicateTaskBody = z.infer<typeof DuplicateTaskBody>;
+
+export const QuickAddParsed = z.object({
+  title: z.string(),
+  tags: z.array(z.string()),
+  boardId: z.string(),
+  boardName: z.string(),
+  due: z.string().nullable(),
+  priority: z.string(),
+});
+export type QuickAddParsed = z.infer<typeof QuickAddParsed>;
+
+export const QuickAdd = z.object({
+  parsed: QuickAddParsed,
+  id: z.string().nullable(),
+  key: z.string().nullable(),
+});
+export type QuickAdd = z.infer<typeof QuickAdd>;
+
+export const QuickAddBody = z.object({
+  text: z.string(),
+  preview: z.boolean().optional(),
+});
+export type QuickAddBody = z.infer<typeof QuickAddBody>;
+
+export const TaskBacklink = z.object({
+  taskId: z.string(),
+  taskKey: z.string(),
+  taskTitle: z.string(),
+  projectId: z.string(),
+  commentId: z.string().nullable(),
+  created: z.number(),
+});
+export type TaskBacklink = z.infer<typeof TaskBacklink>;
+
+export const GetTaskBacklinks = z.object({
+  backlinks: z.array(TaskBacklink),
+});
+export type GetTaskBacklinks = z.infer<typeof GetTaskBacklinks>;
+
+export const BulkTaskResult = z.object({
+  taskId: z.string(),
+  status: z.string(),
+  error: z.string().nullable(),
+});
+export type BulkTaskResult = z.infer<typeof BulkTaskResult>;
+
+export const BulkTasks = z.object({
+  applied: z.boolean(),
+  results: z.array(BulkTaskResult),
+});
+export type BulkTasks = z.infer<typeof BulkTasks>;
+
+export const BulkTasksBody = z.object({
+  taskIds: z.array(z.string()),
+  action: z.string(),
+  boardId: z.string().optional(),
+  tags: z.array(z.string()).optional(),
+});
+export type BulkTasksBody = z.infer<typeof BulkTasksBody>;
+
+export const TaskWatcher = z.object({
+  id: z.string(),
+  displayName: z.string(),
+  via: z.string(),
+});
+export type TaskWatcher = z.infer<typeof TaskWatcher>;
+
+export const GetTaskWatchers = z.object({
+  watchers: z.array(TaskWatcher),
+});
+export type GetTaskWatchers = z.infer<typeof GetTaskWatchers>;
+
+export const TaskSubscription = z.object({
+  taskId: z.string(),
+  taskKey: z.string(),
+  taskTitle: z.string(),
+  projectId: z.string(),
+  created: z.number(),
+});
+export type TaskSubscription = z.infer<typeof TaskSubscription>;
+
+export const BoardSubscription = z.object({
+  boardId: z.string(),
+  boardName: z.string(),
+  projectId: z.string(),
+  created: z.number(),
+});
+export type BoardSubscription = z.infer<typeof BoardSubscription>;
+
+export const GetUserSubscriptions = z.object({
+  tasks: z.array(TaskSubscription),
+  boards: z.array(BoardSubscription),
+});
+export type GetUserSubscriptions = z.infer<typeof GetUserSubscriptions>;
+
 export const Signup = z.object({
   id: z.string(),
   username: z.string(),
@@ -132,6 +845,7 @@ export const GetMe = z.object({
   username: z.string(),
   role: z.string(),
   displayName: z.string(),
+  timezone: z.string(),
 });
 export type GetMe = z.infer<typeof GetMe>;
 
@@ -143,6 +857,7 @@ export type GetSystemInfo = z.infer<typeof GetSystemInfo>;
 export const UpdateUserSettingsBody = z.object({
   displayName: z.string().nullable().optional(),
   quickPlaylist: z.string().nullable().optional(),
+  timezone: z.string().nullable().optional(),
 });
 export type UpdateUserSettingsBody = z.infer<typeof UpdateUserSettingsBody>;
 